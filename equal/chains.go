@@ -0,0 +1,61 @@
+package equal
+
+import "go/types"
+
+// findChain returns the field linking values of the named struct type to values of the same type, e.g.: Next in
+// type Node struct{ Next *Node }, or nil if it has none. The first such field is returned.
+// Equal and Diff functions walk the chain of values linked by the field with a loop, instead of calling themselves for every value,
+// so comparing long linked lists doesn't need a deep stack.
+// Skipped fields, fields compared by functions named in their tags and fields with the semantic tag are not walked.
+func (g *Generator) findChain(typ *types.Named) *types.Var {
+	structType, ok := typ.Underlying().(*types.Struct)
+	if !ok {
+		return nil
+	}
+	for i := 0; i < structType.NumFields(); i++ {
+		field := structType.Field(i)
+		if tag := g.getTag(field); tag.skip || tag.function != "" || tag.semantic {
+			continue
+		}
+		if pointer, ok := types.Unalias(field.Type()).(*types.Pointer); ok && isSelf(pointer.Elem(), typ) {
+			return field
+		}
+	}
+	return nil
+}
+
+// setChain stores the field linking the values of the named type, if it has one, walked by the loop of the function of myType.
+// Values compared with reflect.DeepEqual are not walked, and neither are fields that are not exported, outside their package.
+func (g *Generator) setChain(myType Type, named *types.Named) {
+	field := g.findChain(named)
+	if field == nil || g.reflected[myType] || (!field.Exported() && field.Pkg().Path() != myType.pkgPath) {
+		return
+	}
+	g.chains[myType] = field
+}
+
+// isSelf returns true if typ is the named type, or the generic named type instantiated with its own type parameters,
+// as it refers itself in its declaration, e.g.: Node[T] in type Node[T any] struct{ Next *Node[T] }.
+func isSelf(typ types.Type, named *types.Named) bool {
+	elem, ok := types.Unalias(typ).(*types.Named)
+	if !ok {
+		return false
+	}
+	if types.Identical(elem, named) {
+		return true
+	}
+	if elem.Origin() != named.Origin() || named.TypeParams().Len() == 0 || elem.TypeArgs().Len() != named.TypeParams().Len() {
+		return false
+	}
+	for i := 0; i < elem.TypeArgs().Len(); i++ {
+		if elem.TypeArgs().At(i) != named.TypeParams().At(i) {
+			return false
+		}
+	}
+	return true
+}
+
+// isChain returns true if field is walked by the loop of the current Equal or Diff function, so it is not compared by a call.
+func (g *Generator) isChain(field *types.Var) bool {
+	return !g.hash && !g.clone && g.chains[g.usedTypes[len(g.usedTypes)-1]] == field
+}
@@ -0,0 +1,81 @@
+package equal
+
+import (
+	"container/list"
+	"reflect"
+	"sync"
+)
+
+// ListEqual returns true if a and b have the same length and deeply equal values, in the same order.
+func ListEqual(a, b *list.List) bool {
+	if a.Len() != b.Len() {
+		return false
+	}
+	for e1, e2 := a.Front(), b.Front(); e1 != nil; e1, e2 = e1.Next(), e2.Next() {
+		if !reflect.DeepEqual(e1.Value, e2.Value) {
+			return false
+		}
+	}
+	return true
+}
+
+// CloneList appends to dst, which is emptied first, the values of src.
+func CloneList(dst, src *list.List) {
+	dst.Init()
+	for e := src.Front(); e != nil; e = e.Next() {
+		dst.PushBack(e.Value)
+	}
+}
+
+// ListValues returns the values of l, in order.
+// Generated Diff functions report them, as the list itself is formatted with its pointers.
+func ListValues(l *list.List) []any {
+	values := make([]any, 0, l.Len())
+	for e := l.Front(); e != nil; e = e.Next() {
+		values = append(values, e.Value)
+	}
+	return values
+}
+
+// SyncMapEqual returns true if a and b have the same keys and deeply equal values for each key.
+// The maps are not locked as a whole, so they should not be modified while they are compared.
+func SyncMapEqual(a, b *sync.Map) bool {
+	if a == b {
+		return true
+	}
+	equal, n := true, 0
+	a.Range(func(key, value any) bool {
+		n++
+		other, ok := b.Load(key)
+		equal = ok && reflect.DeepEqual(value, other)
+		return equal
+	})
+	if !equal {
+		return false
+	}
+	// every key of a is in b, so b has other keys only if it has more entries
+	b.Range(func(key, value any) bool {
+		n--
+		return n >= 0
+	})
+	return n == 0
+}
+
+// CloneSyncMap stores in dst every entry of src.
+func CloneSyncMap(dst, src *sync.Map) {
+	src.Range(func(key, value any) bool {
+		dst.Store(key, value)
+		return true
+	})
+}
+
+// SyncMapValues returns the entries of m in a map.
+// Generated Diff functions report them, as the map itself is formatted with its internals.
+func SyncMapValues(m *sync.Map) map[any]any {
+	values := make(map[any]any)
+	m.Range(func(key, value any) bool {
+		values[key] = value
+		return true
+	})
+	return values
+}
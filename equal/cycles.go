@@ -0,0 +1,120 @@
+package equal
+
+import (
+	"go/types"
+	"strings"
+)
+
+// isCyclic returns true if values of the named type can form cycles, e.g.: type Node struct{ Next *Node },
+// that is if the type can be reached from itself through its fields, elements, keys and pointers.
+// Types not compared by our functions, e.g.: ignored types or types with Equal methods, and interfaces are not followed.
+// Generic types are not cyclic, as their functions get comparators, their instances can be.
+func (g *Generator) isCyclic(typ *types.Named) bool {
+	if hasTypeParams(typ) {
+		return false
+	}
+	key := types.TypeString(typ, nil)
+	if cyclic, ok := g.cyclic[key]; ok {
+		return cyclic
+	}
+	seen := make(map[string]bool)
+	var reaches func(t types.Type) bool
+	reaches = func(t types.Type) bool {
+		switch t := types.Unalias(t).(type) {
+		case *types.Named:
+			name := types.TypeString(t, nil)
+			if name == key {
+				return true
+			}
+			if seen[name] {
+				return false
+			}
+			seen[name] = true
+			if ok, _ := g.getEqualFunctionName(t); ok || g.getEqualMethod(t) != nil {
+				return false
+			}
+			if _, ok := g.getStdlibComparison(t); ok {
+				return false
+			}
+			return reaches(t.Underlying())
+		case *types.Pointer:
+			return reaches(t.Elem())
+		case *types.Slice:
+			return reaches(t.Elem())
+		case *types.Array:
+			return reaches(t.Elem())
+		case *types.Map:
+			return reaches(t.Key()) || reaches(t.Elem())
+		case *types.Struct:
+			for i := 0; i < t.NumFields(); i++ {
+				field := t.Field(i)
+				// skipped fields and fields compared by functions named in their tags are not compared by our functions
+				if tag := g.getTag(field); tag.skip || tag.function != "" {
+					continue
+				}
+				if reaches(field.Type()) {
+					return true
+				}
+			}
+		}
+		return false
+	}
+	cyclic := reaches(typ.Underlying())
+	g.cyclic[key] = cyclic
+	return cyclic
+}
+
+// passesVisited returns true if the function of the named type, called by the current function, gets the visited pairs.
+// Cyclic types get them from cyclic types of the same package, as cycles can't cross packages,
+// other types start with no visited pairs. Hash and Clone functions don't detect cycles.
+func (g *Generator) passesVisited(typ *types.Named) bool {
+	if !g.options.CycleSafe || g.hash || g.clone {
+		return false
+	}
+	current := g.usedTypes[len(g.usedTypes)-1]
+	if !g.cyclicTypes[current] || !g.isCyclic(typ) {
+		return false
+	}
+	// functions of instances and of types of other modules are generated in the current package, and with the Out option every function
+	isLocal := typ.TypeArgs().Len() > 0 || g.options.Out != "" || g.isForeign(typ.Obj().Pkg().Path())
+	return isLocal || typ.Obj().Pkg().Path() == current.pkgPath
+}
+
+// getVisitedName returns the name of the unexported function, or method, of a cyclic type getting the visited pairs.
+// e.g.: EqualNode -> equalNodeVisited, Diff -> diffVisited
+func getVisitedName(funcName string) string {
+	return strings.ToLower(funcName[:1]) + funcName[1:] + "Visited"
+}
+
+// visitsMap returns true if the current function records the pairs of maps of the type, as their keys or values can lead back to a cyclic type.
+// Map values are copied before being compared, so their pairs have new addresses every time and are never visited again, the maps are.
+func (g *Generator) visitsMap(mapType *types.Map) bool {
+	if !g.options.CycleSafe || g.hash || g.clone || !g.cyclicTypes[g.usedTypes[len(g.usedTypes)-1]] {
+		return false
+	}
+	return g.reachesCyclic(mapType.Key()) || g.reachesCyclic(mapType.Elem())
+}
+
+// reachesCyclic returns true if typ is, or reaches through its fields, elements, keys and pointers, a named type whose values can form cycles.
+// The first named type reached decides, as a cycle through typ passes only through cyclic types.
+func (g *Generator) reachesCyclic(typ types.Type) bool {
+	switch t := types.Unalias(typ).(type) {
+	case *types.Named:
+		return g.isCyclic(t)
+	case *types.Pointer:
+		return g.reachesCyclic(t.Elem())
+	case *types.Slice:
+		return g.reachesCyclic(t.Elem())
+	case *types.Array:
+		return g.reachesCyclic(t.Elem())
+	case *types.Map:
+		return g.reachesCyclic(t.Key()) || g.reachesCyclic(t.Elem())
+	case *types.Struct:
+		for i := 0; i < t.NumFields(); i++ {
+			if tag := g.getTag(t.Field(i)); !tag.skip && tag.function == "" && g.reachesCyclic(t.Field(i).Type()) {
+				return true
+			}
+		}
+	}
+	return false
+}
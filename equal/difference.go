@@ -0,0 +1,59 @@
+package equal
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Reason tells why two values are different.
+type Reason string
+
+// Reasons reported by generated Diff functions.
+const (
+	DifferentValues  Reason = "different values"
+	DifferentLengths Reason = "different lengths"
+	MissingKey       Reason = "missing key"
+	MissingElement   Reason = "missing element" // an element of a slice compared as a multiset has no equal element in the other slice
+	DuplicateKey     Reason = "duplicate key"   // two slices matched by key have a different number of elements with the same key
+	NilValue         Reason = "nil vs non-nil"
+)
+
+// Difference is a difference found by a generated Diff function.
+type Difference struct {
+	Path   string // path of the differing value, e.g.: Orders[3].Lines["sku-1"].Qty, empty for the compared values
+	Value1 string // first value, formatted; empty for a missing key
+	Value2 string // second value, formatted; empty for a missing key
+	Reason Reason
+}
+
+func (d Difference) String() string {
+	return fmt.Sprintf("%s: %s: %s != %s", d.Path, d.Reason, d.Value1, d.Value2)
+}
+
+// Nested appends to diffs the differences found for a nested value at path.
+// The paths of the nested differences are relative to the nested value, so they are prefixed with path.
+func Nested(diffs []Difference, path string, nested []Difference) []Difference {
+	for _, d := range nested {
+		switch {
+		case d.Path == "":
+			d.Path = path
+		case path == "":
+		case strings.HasPrefix(d.Path, "["):
+			d.Path = path + d.Path
+		default:
+			d.Path = path + "." + d.Path
+		}
+		diffs = append(diffs, d)
+	}
+	return diffs
+}
+
+// ChainPath returns the path of the value reached by following the field depth times,
+// as reported by generated Diff functions walking a chain of values linked by the field.
+// e.g.: Next, 3 -> Next.Next.Next
+func ChainPath(field string, depth int) string {
+	if depth == 0 {
+		return ""
+	}
+	return field + strings.Repeat("."+field, depth-1)
+}
@@ -0,0 +1,151 @@
+package equal
+
+import (
+	"bytes"
+	"fmt"
+	"go/types"
+	"sort"
+)
+
+// parseInterface generates a type switch comparing two interface values with the function of their dynamic type,
+// for the named types of the loaded packages implementing the interface.
+// Values of any other dynamic type are compared with interfaceEqual, which is equal.Interface.
+func (g *Generator) parseInterface(name string, iface *types.Interface, isType bool, interfaceEqual string) string {
+	name1, name2 := getNames(name, isType)
+	failure := g.getFailure(name, isType, "DifferentValues", name1, name2)
+	fallback := g.getCheck(fmt.Sprintf("!%s(%s, %s)", interfaceEqual, name1, name2), failure, "")
+	implementations := g.getImplementations(iface)
+	if len(implementations) == 0 {
+		return fallback
+	}
+	var result bytes.Buffer
+	result.WriteString(fmt.Sprintf("switch v1 := %s.(type) {\n", name1))
+	for _, typ := range implementations {
+		call := g.getDispatchCall(typ)
+		if call == "" {
+			continue
+		}
+		funcCall := fmt.Sprintf("if !%s {\nreturn false\n}\n", call)
+		if g.diff {
+			// differences found by the called function are relative to the compared values
+			funcCall = fmt.Sprintf("diffs = %s(diffs, %s, %s)\n", g.getReferenceUpdateImports(equalPkgPath, "Nested"), g.getPath(name, isType), call)
+		}
+		typeName := types.TypeString(typ, g.qualifier)
+		// values of different dynamic types are different
+		result.WriteString(fmt.Sprintf("case %s:\nv2, ok := %s.(%s)\n%s", typeName, name2, typeName, g.getCheck("!ok", failure, funcCall)))
+	}
+	result.WriteString(fmt.Sprintf("default:\n%s}\n", fallback))
+	return result.String()
+}
+
+// getDispatchCall returns the call comparing v1 and v2, the values of typ in a type switch.
+// typ is a named type or a pointer to a named type. It returns empty string if the function can not be generated.
+func (g *Generator) getDispatchCall(typ types.Type) string {
+	named, ok := typ.(*types.Named)
+	isPointer := !ok
+	if isPointer {
+		named = typ.(*types.Pointer).Elem().(*types.Named)
+	}
+	funcName, ok := g.getFuncName(named)
+	if !ok {
+		return ""
+	}
+	// v1 and v2 are addressable, so values of types whose functions take pointers are passed by address
+	arg1, arg2 := "v1", "v2"
+	if !isPointer && !g.isPointer(named.Underlying()) {
+		arg1, arg2 = "&v1", "&v2"
+	}
+	if g.isMethod(named) {
+		return fmt.Sprintf("v1.%s(%s)", g.getOperation(), arg2)
+	}
+	return fmt.Sprintf("%s(%s, %s)", funcName, arg1, arg2)
+}
+
+// getImplementations returns the types implementing the interface, among the named types of the loaded packages
+// and the pointers to them, so that they can be compared with their functions.
+// Every type implements the empty interface, so it has no implementations.
+func (g *Generator) getImplementations(iface *types.Interface) []types.Type {
+	if iface.Empty() {
+		return nil
+	}
+	current := g.usedTypes[len(g.usedTypes)-1]
+	var implementations []types.Type
+	for _, named := range g.getNamedTypes() {
+		// types of other packages can be named in the type switch only if they are exported
+		if !named.Obj().Exported() && named.Obj().Pkg().Path() != current.pkgPath {
+			continue
+		}
+		// ignored types and types compared by their Equal methods don't have functions
+		if ok, _ := g.getEqualFunctionName(named); ok || g.getEqualMethod(named) != nil {
+			continue
+		}
+		if implements(named, iface) {
+			implementations = append(implementations, named)
+		}
+		// functions of types with an underlying pointer kind take values, so pointers to them are left to equal.Interface
+		if pointer := types.NewPointer(named); !g.isPointer(named.Underlying()) && implements(pointer, iface) {
+			implementations = append(implementations, pointer)
+		}
+	}
+	return implementations
+}
+
+// getNamedTypes returns the named types declared in the loaded packages of the main module, or in the input packages,
+// sorted by package path and name. Generic types and interfaces are left out, as they are not dynamic types of interface values.
+// The types are collected once.
+func (g *Generator) getNamedTypes() []*types.Named {
+	if g.namedTypes != nil {
+		return g.namedTypes
+	}
+	var paths []string
+	if g.input != nil {
+		for path := range g.input {
+			paths = append(paths, path)
+		}
+	} else {
+		for path, loaded := range g.loaded {
+			if loaded.Module != nil && loaded.Module.Main {
+				paths = append(paths, path)
+			}
+		}
+	}
+	sort.Strings(paths)
+	g.namedTypes = []*types.Named{}
+	for _, path := range paths {
+		pkgObj := g.getPkg(path)
+		// packages with problems are left out, their problems are reported if their types are compared
+		if err := pkgObj.checked(); err != nil {
+			continue
+		}
+		scope := pkgObj.types.Scope()
+		for _, name := range scope.Names() {
+			typeName, ok := scope.Lookup(name).(*types.TypeName)
+			if !ok || typeName.IsAlias() {
+				continue
+			}
+			named, ok := typeName.Type().(*types.Named)
+			if !ok || named.TypeParams().Len() > 0 {
+				continue
+			}
+			if _, isInterface := named.Underlying().(*types.Interface); isInterface {
+				continue
+			}
+			g.namedTypes = append(g.namedTypes, named)
+		}
+	}
+	return g.namedTypes
+}
+
+// implements returns true if the method set of typ has every method of the interface.
+// They can come from different type checks of the same packages, so methods are matched by name and signature.
+func implements(typ types.Type, iface *types.Interface) bool {
+	methods := types.NewMethodSet(typ)
+	for i := 0; i < iface.NumMethods(); i++ {
+		method := iface.Method(i)
+		selection := methods.Lookup(method.Pkg(), method.Name())
+		if selection == nil || !identical(selection.Obj().Type(), method.Type()) {
+			return false
+		}
+	}
+	return true
+}
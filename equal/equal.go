@@ -5,54 +5,49 @@ import (
 	"bytes"
 	"fmt"
 	"go/ast"
-	"go/build"
 	"go/format"
 	"go/importer"
 	"go/parser"
 	"go/token"
 	"go/types"
 	"io/ioutil"
-	"log"
+	"os"
 	"os/exec"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
 	"unicode"
-)
 
-func init() {
-	log.SetFlags(log.Llongfile)
-}
+	"golang.org/x/tools/go/packages"
+)
 
 var goroot string
 
 // getGOROOT returns the value of GOROOT on the installation where go runs.
-func getGOROOT() string {
+func getGOROOT() (string, error) {
 	if goroot != "" {
-		return goroot
+		return goroot, nil
 	}
-	if out, err := exec.Command("go", "list", "-f", "{{.Dir}}", "runtime").Output(); err != nil {
-		log.Fatalf("can not dermine GOROOT: %s", err)
-	} else {
-		goroot = strings.TrimSuffix(strings.TrimSpace(string(out)), filepath.Join("src", "runtime"))
+	out, err := exec.Command("go", "list", "-f", "{{.Dir}}", "runtime").Output()
+	if err != nil {
+		return "", fmt.Errorf("can not determine GOROOT: %s", err)
 	}
-	return goroot
+	goroot = strings.TrimSuffix(strings.TrimSpace(string(out)), filepath.Join("src", "runtime"))
+	return goroot, nil
 }
 
-// header is how we start the comment for generated files.
-var header = []byte("// Code generated by goequal for type:")
+// header is how we start the comment for generated files, followed by the type, or the types, they are generated for.
+var header = []byte("// Code generated by goequal for type")
 
 // goFormat returns the gofmt-ed contents of the Generator's buffer.
-func goFormat(content []byte) []byte {
+// The generated code is not valid Go if it can not be formatted, which should never happen, but can arise when developing this code.
+func goFormat(content []byte) ([]byte, error) {
 	src, err := format.Source(content)
 	if err != nil {
-		// Should never happen, but can arise when developing this code.
-		// The user can compile the output to see the error.
-		log.Printf("warning: internal error: invalid Go generated: %s\n", err)
-		log.Printf("warning: compile the package to analyze the error")
-		return content
+		return nil, fmt.Errorf("internal error: invalid Go generated: %s", err)
 	}
-	return src
+	return src, nil
 }
 
 // Type completely identifies a type.
@@ -78,86 +73,192 @@ func newCode(typeName string, pkg *pkg) *code {
 }
 
 // serialize generates the final content.
-// returns the path and the content to be written on disk.
-func (c *code) serialize() (string, []byte) {
-	path := filepath.Join(c.pkg.dir, fmt.Sprintf("goequal_%s.go", c.typeName))
+// returns the path and the content to be written on disk, or an error if the content is not valid Go.
+func (c *code) serialize() (string, []byte, error) {
+	return serialize(fmt.Sprintf("goequal_%s.go", c.typeName), []*code{c})
+}
+
+// serialize generates the content of the file named fileName, with the functions of codes, which are of the same package.
+// The imports of every code are combined and sorted, and the header lists every type.
+// returns the path and the content to be written on disk, or an error if the content is not valid Go.
+func serialize(fileName string, codes []*code) (string, []byte, error) {
+	pkg := codes[0].pkg
+	path := filepath.Join(pkg.dir, fileName)
+	typeNames := make([]string, 0, len(codes))
+	imports := make(map[string]struct{})
+	for _, c := range codes {
+		typeNames = append(typeNames, c.typeName)
+		for importPath := range c.imports {
+			imports[importPath] = struct{}{}
+		}
+	}
 	var content bytes.Buffer
-	content.WriteString(fmt.Sprintf("// Code generated by goequal for type: %s; DO NOT EDIT\n", c.typeName))
-	content.WriteString(fmt.Sprintf("package %s\n", c.pkg.name))
-	for importPath := range c.imports {
-		localImportName := c.pkg.imports[importPath]
+	if len(codes) == 1 {
+		content.WriteString(fmt.Sprintf("%s: %s; DO NOT EDIT\n", header, typeNames[0]))
+	} else {
+		content.WriteString(fmt.Sprintf("%ss: %s; DO NOT EDIT\n", header, strings.Join(typeNames, ", ")))
+	}
+	content.WriteString(fmt.Sprintf("package %s\n", pkg.name))
+	importPaths := make([]string, 0, len(imports))
+	for importPath := range imports {
+		importPaths = append(importPaths, importPath)
+	}
+	sort.Strings(importPaths)
+	for _, importPath := range importPaths {
+		localImportName := pkg.imports[importPath]
 		content.WriteString(fmt.Sprintf("import %s \"%s\"\n", localImportName, importPath))
 	}
-	content.WriteString(c.code)
-	return path, goFormat(content.Bytes())
+	for i, c := range codes {
+		if i > 0 {
+			content.WriteString("\n\n")
+		}
+		content.WriteString(c.code)
+	}
+	src, err := goFormat(content.Bytes())
+	return path, src, err
+}
+
+// loadMode is what we need from the loader: syntax and types for every package, type checked from source.
+const loadMode = packages.NeedName | packages.NeedFiles | packages.NeedCompiledGoFiles | packages.NeedImports |
+	packages.NeedDeps | packages.NeedTypes | packages.NeedSyntax | packages.NeedTypesInfo | packages.NeedModule
+
+// packageImporter resolves imports of a package from its already loaded dependencies.
+type packageImporter map[string]*packages.Package
+
+func (imp packageImporter) Import(path string) (*types.Package, error) {
+	if path == "unsafe" {
+		return types.Unsafe, nil
+	}
+	if loaded, ok := imp[path]; ok && loaded.Types != nil {
+		return loaded.Types, nil
+	}
+	return nil, fmt.Errorf("package %s was not loaded", path)
+}
+
+// loadImporter resolves any package, loading it if it is not a dependency of the loaded packages.
+type loadImporter struct {
+	g *Generator
+}
+
+func (imp *loadImporter) Import(path string) (*types.Package, error) {
+	if _, ok := imp.g.loaded[path]; !ok {
+		if _, err := imp.g.load(path); err != nil {
+			return nil, err
+		}
+	}
+	return packageImporter(imp.g.loaded).Import(path)
+}
+
+// inputImporter resolves imports of packages given as input: other input packages are type checked from their code,
+// any other package is imported with the default importer; test purposes only.
+type inputImporter struct {
+	g   *Generator
+	std types.Importer
+}
+
+func (imp *inputImporter) Import(path string) (*types.Package, error) {
+	if _, ok := imp.g.input[path]; !ok {
+		if imp.std == nil {
+			imp.std = importer.Default()
+		}
+		return imp.std.Import(path)
+	}
+	pkgObj := imp.g.getPkg(path)
+	if err := pkgObj.checked(); err != nil {
+		return nil, err
+	}
+	return pkgObj.types, nil
 }
 
 // pkg describes a parsed go package and will return a go node by name.
 type pkg struct {
 	name, path, dir string                      // path is package import path
 	input           interface{}                 // string containing the code for this package, if given use this instead of reading the content of package from disk; testing purposes only
+	loaded          *packages.Package           // package as returned by the loader, nil if input is given
+	fset            *token.FileSet              // positions of the checked files
+	importer        types.Importer              // resolves imports of the package
+	resolver        types.Importer              // resolves any package, e.g.: packages of functions named in goequal tags
+	types           *types.Package              // the type checked package
 	defs            map[*ast.Ident]types.Object // map go identifiers to nodes
+	checkErr        error                       // problems found when checking the package, so we check it only once
 	imports         map[string]string           // maps  import path with local import name ( TODO: local import name can be . ??? )
+	tags            map[token.Pos]fieldTag      // maps struct fields with their goequal tags, by position
+	ignored         map[token.Pos]bool          // set with type declarations having the ignore directive, by position
+	annotated       map[token.Pos]bool          // set with type declarations having the generate directive, by position
 }
 
-func newPkg(path string, input interface{}) *pkg {
+func newPkg(path string, input interface{}, loaded *packages.Package, importer types.Importer) *pkg {
 	return &pkg{
-		path:    path,
-		input:   input,
-		imports: make(map[string]string),
+		path:     path,
+		input:    input,
+		loaded:   loaded,
+		importer: importer,
+		imports:  make(map[string]string),
 	}
 }
 
-// testDiscover discovers a package that is delivered only through source code that doesn't exist on disk.
-func (p *pkg) testDiscover() []string {
+// testDiscover parses a package that is delivered only through source code that doesn't exist on disk.
+func (p *pkg) testDiscover() (*token.FileSet, []*ast.File, error) {
 	p.name = p.path[strings.Index(p.path, "/")+1:]
-	return []string{"test.go"}
+	fs := token.NewFileSet()
+	parsedFile, err := parser.ParseFile(fs, "test.go", p.input, parser.ParseComments)
+	if err != nil {
+		return nil, nil, fmt.Errorf("parsing file: %s: %s", "test.go", err)
+	}
+	return fs, []*ast.File{parsedFile}, nil
 }
 
-// discover discovers files in the package.
+// discover returns the parsed files of the loaded package.
 // name and dir are needed.
-func (p *pkg) discover() []string {
-	pkgObj, err := build.Default.Import(p.path, "", 0)
-	if err != nil {
-		log.Fatalf("cannot process package %s: %s\n", p.path, err)
+func (p *pkg) discover() ([]*ast.File, error) {
+	if p.loaded == nil {
+		return nil, fmt.Errorf("package %s was not loaded", p.path)
 	}
-	p.name = pkgObj.Name
-	p.dir = pkgObj.Dir
-	files := make([]string, 0, 1)
-	for _, fileName := range pkgObj.GoFiles {
+	p.name = p.loaded.Name
+	p.dir = p.loaded.Dir
+	files := make([]*ast.File, 0, len(p.loaded.Syntax))
+	for _, file := range p.loaded.Syntax {
+		fileName := p.loaded.Fset.File(file.Pos()).Name()
 		// if this is one of our files, we want it to be skipped from checks, as code that is based upon might have been changed
-		if strings.HasPrefix(fileName, "goequal_") {
-			content, err := ioutil.ReadFile(filepath.Join(pkgObj.Dir, fileName))
-			if err != nil {
-				log.Fatalf(err.Error())
-			}
-			if bytes.HasPrefix(content, header) {
-				continue
-			}
+		generated, err := isGenerated(fileName)
+		if err != nil {
+			return nil, err
+		}
+		if !generated {
+			files = append(files, file)
 		}
-		files = append(files, filepath.Join(pkgObj.Dir, fileName))
 	}
-	for _, fileName := range pkgObj.CgoFiles {
-		files = append(files, filepath.Join(pkgObj.Dir, fileName))
+	return files, nil
+}
+
+// isGenerated returns true if the file was generated by us.
+func isGenerated(fileName string) (bool, error) {
+	if !strings.HasPrefix(filepath.Base(fileName), "goequal_") {
+		return false, nil
+	}
+	content, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return false, err
 	}
-	return files
+	return bytes.HasPrefix(content, header), nil
 }
 
-// check sets all definitions resulted from parsing the package.
-func (p *pkg) check() {
-	var files []string
+// check sets all definitions resulted from type checking the package.
+// It returns all the problems found in the package.
+func (p *pkg) check() error {
+	var astFiles []*ast.File
+	var err error
 	if p.input != nil {
-		files = p.testDiscover()
+		p.fset, astFiles, err = p.testDiscover()
 	} else {
-		files = p.discover()
+		p.fset = p.loaded.Fset
+		astFiles, err = p.discover()
 	}
-	var astFiles []*ast.File
-	fs := token.NewFileSet()
-	for _, file := range files {
-		parsedFile, err := parser.ParseFile(fs, file, p.input, 0)
-		if err != nil {
-			log.Fatalf("parsing file: %s: %s", file, err)
-		}
+	if err != nil {
+		return err
+	}
+	var errs Errors
+	for _, parsedFile := range astFiles {
 		// calculate imports by this package
 		// do not allow to import the same package under two different names
 		for _, importSpec := range parsedFile.Imports {
@@ -165,137 +266,479 @@ func (p *pkg) check() {
 			if importSpec.Name != nil {
 				importName = importSpec.Name.Name
 			}
+			// blank imports can not be referred, cgo processed files use them for their own imports
+			if importName == "_" {
+				continue
+			}
 			path := importSpec.Path.Value[1 : len(importSpec.Path.Value)-1] // remove enclosing quotes
 			if oldImportName, ok := p.imports[path]; ok {
 				// we simply don't like if the next condition is satisfied
 				if oldImportName != importName {
-					log.Fatalf("package:%s imports package:%s under two names:%s %s", p.path, path, oldImportName, importName)
+					errs = append(errs, &Error{
+						Pos: p.fset.Position(importSpec.Pos()),
+						Msg: fmt.Sprintf("package:%s imports package:%s under two names:%s %s", p.path, path, oldImportName, importName),
+					})
 				}
 			} else {
 				p.imports[path] = importName
 			}
 		}
-		astFiles = append(astFiles, parsedFile)
 	}
-	config := types.Config{Importer: importer.Default(), FakeImportC: true}
+	config := types.Config{
+		Importer:    p.importer,
+		FakeImportC: true,
+		Error: func(err error) {
+			if typeErr, ok := err.(types.Error); ok {
+				errs = append(errs, &Error{Pos: typeErr.Fset.Position(typeErr.Pos), Msg: typeErr.Msg})
+				return
+			}
+			errs.add(err)
+		},
+	}
 	defs := make(map[*ast.Ident]types.Object)
 	info := &types.Info{
 		Defs: defs,
 	}
-	_, err := config.Check(p.path, fs, astFiles, info)
-	if err != nil {
-		log.Fatalf("checking package: %s", err)
+	typesPkg, _ := config.Check(p.path, p.fset, astFiles, info)
+	errs = append(errs, p.annotations(astFiles, typesPkg, defs)...)
+	if len(errs) > 0 {
+		return errs
 	}
+	p.types = typesPkg
 	p.defs = defs
+	return nil
+}
+
+// checked type checks the package only the first time it is called.
+// It returns the problems found in the package.
+func (p *pkg) checked() error {
+	if p.defs == nil && p.checkErr == nil {
+		p.checkErr = p.check()
+	}
+	return p.checkErr
 }
 
 // findObj returns the type by name.
-// stops program if the type is not found.
-func (p *pkg) findObj(name string) types.Object {
-	if p.defs == nil {
-		p.check()
+// returns an error if the package has problems or the type is not found.
+func (p *pkg) findObj(name string) (types.Object, error) {
+	if err := p.checked(); err != nil {
+		return nil, err
 	}
 	for key := range p.defs {
 		if key.Name == name {
 			obj := p.defs[key]
 			// we are looking for named types only
 			if _, ok := obj.Type().(*types.Named); ok {
-				return obj
+				return obj, nil
 			}
 		}
 	}
-	log.Fatalf("Type:%s was not found in package:%s", name, p.path)
-	return nil
+	return nil, &Error{Msg: fmt.Sprintf("Type:%s was not found in package:%s", name, p.path)}
+}
+
+// Options configures the generated code.
+type Options struct {
+	Method bool // generate Equal methods instead of Equal<Type> functions, for types that can have methods
+	Diff   bool // generate also Diff<Type> functions, reporting every difference
+	Hash   bool // generate also Hash<Type> functions, consistent with the Equal functions
+	Clone  bool // generate also Clone<Type> functions, copying what the Equal functions compare
+	// NaNEqual makes NaN floating-point numbers equal to each other, so every value is equal to itself
+	NaNEqual bool
+	// Semantic makes a nil pointer equal to a pointer to a zero value, e.g.: after a JSON round trip
+	Semantic bool
+	// Dispatch compares interface values with the functions of their dynamic types, for the types of the loaded packages
+	Dispatch bool
+	// Register registers the generated functions in init functions, so equal.Interface compares interface values with them
+	Register bool
+	// CycleSafe makes the Equal and Diff functions of types whose values can form cycles end for cyclic values, as reflect.DeepEqual does
+	CycleSafe bool
+	// SingleFile writes the functions of every package in a single file, goequal_equal.go, instead of a file per type
+	SingleFile bool
+	// Out is the package every function is generated in, e.g.: ./internal/equality, instead of the packages of the types.
+	// The functions compare only exported fields, types with fields that are not exported are reported, unless Unexported says otherwise.
+	Out string
+	// All generates also the functions of every named struct, map and slice type declared in the package
+	All bool
+	// AllAnnotated generates also the functions of every type declared with the generate directive, //goequal:generate, in the packages
+	AllAnnotated bool
+	// Unexported is the strategy for the fields that are not exported of types compared outside their package,
+	// e.g.: with Out or in the helper functions of types of other modules: UnexportedSkip or UnexportedReflect. They are reported by default.
+	Unexported string
 }
 
+// Strategies for the fields that are not exported of types compared outside their package.
+const (
+	UnexportedSkip    = "skip"    // compare only the exported fields
+	UnexportedReflect = "reflect" // compare the whole values with reflect.DeepEqual
+)
+
+// equalPkgPath is the import path of this package, used by generated Diff functions.
+const equalPkgPath = "github.com/gadumitrachioaiei/goequal/equal"
+
 // Generator generates the code according to a configuration.
 type Generator struct {
 	pkgPath, typeName string
-	input             map[string]interface{} // map between package path and the code it contains, if given, we use this instead of reading the packages content from disk; test purposes only
-	defs              map[string]*pkg        // map pkg import path to pkg objects
-	equals            map[Type]*code         // stores generated functions, maps type names with the generated functions
-	equalsOrder       []Type                 // contains the ordered type names for which we generated functions, so that we can write them in the same order they were generated
-	usedTypes         []Type                 // list with all types put in a stack so we know the current parsed type
-	stdOut            bool                   // write to stdout instead of disk
+	options           Options
+	input             map[string]interface{}       // map between package path and the code it contains, if given, we use this instead of reading the packages content from disk; test purposes only
+	loaded            map[string]*packages.Package // map pkg import path to packages loaded from source, together with all their dependencies
+	defs              map[string]*pkg              // map pkg import path to pkg objects
+	equals            map[Type]*code               // stores generated functions, maps type names with the generated functions
+	equalsOrder       []Type                       // contains the ordered type names for which we generated functions, so that we can write them in the same order they were generated
+	usedTypes         []Type                       // list with all types put in a stack so we know the current parsed type
+	instances         map[Type]*types.Named        // maps generated functions for instantiated generic types with their instance
+	stdOut            bool                         // write to stdout instead of disk
+	errs              Errors                       // problems found while generating code
+	diff              bool                         // true while generating a Diff function
+	hash              bool                         // true while generating a Hash function
+	clone             bool                         // true while generating a Clone function
+	tag               fieldTag                     // goequal tag of the struct field we generate code for
+	cyclic            map[string]bool              // maps named types with whether their values can form cycles
+	cyclicTypes       map[Type]bool                // set with the types whose functions detect cycles
+	chains            map[Type]*types.Var          // maps types with the field linking their values, walked by a loop
+	outPkgPath        string                       // import path of the package every function is generated in, with the Out option
+	outImports        map[string]string            // maps import paths with the names the output package imports them with
+	thirdParty        map[Type]bool                // set with the types of other modules, whose functions are private helpers of the calling package
+	reflected         map[Type]bool                // set with the types compared with reflect.DeepEqual, with the UnexportedReflect strategy
+	namedTypes        []*types.Named               // named types of the loaded packages, the dynamic types interface values are dispatched to
 }
 
 // NewGenerator creates a Equal generator for specified type.
-func NewGenerator(pkgPath, typeName string, stdOut bool, input map[string]interface{}) *Generator {
+// pkgPath can be an import path or a relative path like ./internal/model, resolved in the current module.
+// typeName can name many types, separated by commas, and have regular expressions matching type names, e.g.: Order,^Event.*
+// The packages are loaded and checked once, and the functions of the types reached by many of them are generated once.
+func NewGenerator(pkgPath, typeName string, stdOut bool, input map[string]interface{}, options Options) *Generator {
 	g := Generator{
-		pkgPath:  pkgPath,
-		typeName: typeName,
-		input:    input,
-		defs:     make(map[string]*pkg),
-		equals:   make(map[Type]*code),
-		stdOut:   stdOut,
+		pkgPath:     pkgPath,
+		typeName:    typeName,
+		options:     options,
+		input:       input,
+		loaded:      make(map[string]*packages.Package),
+		defs:        make(map[string]*pkg),
+		equals:      make(map[Type]*code),
+		instances:   make(map[Type]*types.Named),
+		stdOut:      stdOut,
+		cyclic:      make(map[string]bool),
+		cyclicTypes: make(map[Type]bool),
+		chains:      make(map[Type]*types.Var),
+		outImports:  make(map[string]string),
+		thirdParty:  make(map[Type]bool),
+		reflected:   make(map[Type]bool),
 	}
 	return &g
 }
 
 // Generate generates the Equal like function.
 // It writes the code to disk or on stdout.
-func (g *Generator) Generate() {
-	g.parse()
-	// we should save each type in its package in its own file
-	for _, myType := range g.equalsOrder {
-		path, content := g.equals[myType].serialize()
-		if g.stdOut {
+// If any problem is found nothing is written and the returned error is of type Errors, listing all of them.
+// Files are written to temporary files first, renamed when all of them are written, so a file which can not be written
+// leaves the files as they were, but a rename or a removal of an old file which fails can leave some files written.
+func (g *Generator) Generate() (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			// a bug of the generator must not end the process of the caller, so every panic is reported
+			msg := fmt.Sprint(r)
+			if internal, ok := r.(internalError); ok {
+				msg = internal.msg
+			}
+			err = Errors{&Error{Msg: "internal error: " + msg}}
+		}
+	}()
+	if err := g.parse(); err != nil {
+		return err
+	}
+	if !g.stdOut {
+		// the functions written before in single files are kept, so they are not lost or declared twice
+		if g.keepPackageFiles(); len(g.errs) > 0 {
+			return g.errs
+		}
+	}
+	if g.outPkgPath != "" && !g.stdOut {
+		if err := os.MkdirAll(g.defs[g.outPkgPath].dir, 0755); err != nil {
+			return Errors{&Error{Msg: fmt.Sprintf("Can not create dir:%s: %s", g.defs[g.outPkgPath].dir, err)}}
+		}
+	}
+	// we should save each type in its package in its own file, or each package in its own file
+	files := g.getFiles()
+	if g.stdOut {
+		for _, file := range files {
+			_, content, err := serialize(file.name, file.codes)
+			if err != nil {
+				g.formatError(file.codes, err)
+				continue
+			}
 			fmt.Println(string(content))
+		}
+		return g.errs.err()
+	}
+	if g.writeFiles(files); len(g.errs) > 0 {
+		return g.errs
+	}
+	for _, file := range files {
+		if g.options.SingleFile {
+			g.removeTypeFiles(file.codes)
 		} else {
-			if err := ioutil.WriteFile(path, content, 0644); err != nil {
-				log.Fatalf("Can not save file:%s", path)
+			g.removePackageFiles(file.codes)
+		}
+	}
+	return g.errs.err()
+}
+
+// position returns the position of obj in the files of its package.
+func (g *Generator) position(obj types.Object) token.Position {
+	if obj == nil || obj.Pkg() == nil {
+		return token.Position{}
+	}
+	if pkgObj := g.defs[obj.Pkg().Path()]; pkgObj != nil && pkgObj.fset != nil {
+		return pkgObj.fset.Position(obj.Pos())
+	}
+	if loaded := g.loaded[obj.Pkg().Path()]; loaded != nil {
+		return loaded.Fset.Position(obj.Pos())
+	}
+	return token.Position{}
+}
+
+// errorf records a problem found for obj, which is the offending type or field.
+func (g *Generator) errorf(obj types.Object, format string, args ...interface{}) {
+	g.errs.add(&Error{Pos: g.position(obj), Msg: fmt.Sprintf(format, args...)})
+}
+
+// load loads the packages matching pattern, e.g.: ./..., together with all their dependencies, type checked from source.
+// The packages are loaded together, so the packages they share are loaded once. It returns the import paths of the matched packages.
+// Packages given as input are not loaded, as they don't exist on disk, and a pattern ending in /... matches them by prefix; test purposes only.
+func (g *Generator) load(pattern string) ([]string, error) {
+	if pkgPaths := g.matchInput(pattern); len(pkgPaths) > 0 {
+		return pkgPaths, nil
+	}
+	pkgs, err := packages.Load(&packages.Config{Mode: loadMode}, pattern)
+	if err != nil {
+		return nil, fmt.Errorf("cannot load package %s: %s", pattern, err)
+	}
+	if len(pkgs) == 0 {
+		return nil, fmt.Errorf("pattern %s matches no packages", pattern)
+	}
+	// type errors are ignored here, the packages are type checked again without our generated files
+	var errs Errors
+	var pkgPaths []string
+	for _, root := range pkgs {
+		for _, err := range root.Errors {
+			if err.Kind != packages.TypeError {
+				errs = append(errs, &Error{Msg: fmt.Sprintf("cannot process package %s: %s", root.PkgPath, err)})
 			}
 		}
+		pkgPaths = append(pkgPaths, root.PkgPath)
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	packages.Visit(pkgs, nil, func(loaded *packages.Package) {
+		if _, ok := g.loaded[loaded.PkgPath]; !ok {
+			g.loaded[loaded.PkgPath] = loaded
+		}
+	})
+	return pkgPaths, nil
+}
+
+// matchInput returns the import paths of the packages given as input matching pattern, sorted; test purposes only.
+func (g *Generator) matchInput(pattern string) []string {
+	if _, isTest := g.input[pattern]; isTest {
+		return []string{pattern}
+	}
+	prefix, ok := strings.CutSuffix(pattern, "/...")
+	if !ok {
+		return nil
+	}
+	var pkgPaths []string
+	for pkgPath := range g.input {
+		if pkgPath == prefix || strings.HasPrefix(pkgPath, prefix+"/") {
+			pkgPaths = append(pkgPaths, pkgPath)
+		}
 	}
+	sort.Strings(pkgPaths)
+	return pkgPaths
 }
 
-// buildDefault returns package dir and name.
+// findPackage returns package dir and name.
 // It looks in input to check if this is called from tests.
-func (g *Generator) buildDefault(pkgPath string) (string, string) {
+func (g *Generator) findPackage(pkgPath string) (string, string, error) {
 	if _, isTest := g.input[pkgPath]; isTest {
-		return pkgPath, pkgPath
+		return pkgPath, pkgPath, nil
 	}
-	pkgObj, err := build.Default.Import(pkgPath, "", 0)
-	if err != nil {
-		log.Fatalf("cannot process package %s: %s\n", pkgPath, err)
+	loaded := g.loaded[pkgPath]
+	if loaded == nil {
+		// the package is not a dependency of a loaded package, which can happen only for test input
+		loadedPaths, err := g.load(pkgPath)
+		if err != nil {
+			return "", "", err
+		}
+		loaded = g.loaded[loadedPaths[0]]
 	}
-	return pkgObj.Dir, pkgObj.Name
+	return loaded.Dir, loaded.Name, nil
 }
 
 // getEqualFunctionName returns whether the type is custom and the function name used for equality evaluation.
 // If function name is empty string, the type should be ignored.
 func (g *Generator) getEqualFunctionName(typ types.Type) (bool, string) {
-	switch t := typ.(type) {
+	switch t := types.Unalias(typ).(type) {
 	case *types.Named:
-		pkgPath := t.Obj().Pkg().Path()
-		dir, _ := g.buildDefault(pkgPath)
-		if strings.HasPrefix(dir, getGOROOT()) {
+		isGOROOT, err := g.isGOROOT(t.Obj())
+		if err != nil {
+			g.errorf(t.Obj(), "%s", err)
+			return true, ""
+		}
+		if !isGOROOT && g.isIgnored(t.Obj()) {
 			return true, ""
 		}
+		// interfaces of standard library, e.g.: io.Reader, and error have no functions of their own, so they are compared as interfaces
+		if isGOROOT && types.IsInterface(t) {
+			return g.getEqualFunctionName(t.Underlying())
+		}
+		// types with an Equal method are compared by it, wherever they are declared
+		// there is no hash consistent with the method, so their values are not hashed
+		if g.getEqualMethod(t) != nil {
+			return g.hash, ""
+		}
+		if isGOROOT {
+			// named types from standard library are compared as their catalog says, named basic types as basic values
+			if comparison, ok := g.getStdlibComparison(t); ok {
+				return g.hash && comparison.hash == nil, ""
+			}
+			_, isBasic := t.Underlying().(*types.Basic)
+			return !isBasic, ""
+		}
 	case *types.Interface:
-		importPath := "reflect"
-		return true, g.getReferenceUpdateImports(importPath, "DeepEqual")
+		if g.hash {
+			// there is no hash consistent with equal.Interface, so interfaces are not hashed
+			return true, ""
+		}
+		if g.clone {
+			// Clone functions copy interfaces, which are then equal, so the function is not called and its package is not imported
+			return true, "equal.Interface"
+		}
+		// interface values are compared as Equalers, with registered functions or with reflect.DeepEqual
+		return true, g.getReferenceUpdateImports(equalPkgPath, "Interface")
 	case *types.Chan, *types.Signature:
 		return true, ""
 	}
 	return false, ""
 }
 
+// isGOROOT returns true if the type is declared in a standard library package, or in no package, e.g.: error.
+func (g *Generator) isGOROOT(obj types.Object) (bool, error) {
+	if obj.Pkg() == nil {
+		return true, nil
+	}
+	dir, _, err := g.findPackage(obj.Pkg().Path())
+	if err != nil {
+		return false, err
+	}
+	goroot, err := getGOROOT()
+	if err != nil {
+		return false, err
+	}
+	return strings.HasPrefix(dir, goroot), nil
+}
+
+// getEqualMethod returns the method Equal(T) bool or Equal(*T) bool of the named type T, or nil if it has none.
+// The method can be declared with a value or a pointer receiver. Equal methods we generated are not returned,
+// as they are generated again, and interfaces are compared as a whole, so their methods are not returned either.
+func (g *Generator) getEqualMethod(typ *types.Named) *types.Func {
+	if _, ok := typ.Underlying().(*types.Interface); ok {
+		return nil
+	}
+	obj, _, _ := types.LookupFieldOrMethod(typ, true, typ.Obj().Pkg(), "Equal")
+	method, ok := obj.(*types.Func)
+	if !ok {
+		return nil
+	}
+	signature := method.Type().(*types.Signature)
+	params, results := signature.Params(), signature.Results()
+	if params.Len() != 1 || results.Len() != 1 || signature.Variadic() || !identical(results.At(0).Type(), types.Typ[types.Bool]) {
+		return nil
+	}
+	if param := params.At(0).Type(); !identical(param, typ) && !identical(param, types.NewPointer(typ)) {
+		return nil
+	}
+	if loaded := g.loaded[typ.Obj().Pkg().Path()]; loaded != nil {
+		if file := loaded.Fset.File(method.Pos()); file != nil {
+			if generated, err := isGenerated(file.Name()); err != nil || generated {
+				return nil
+			}
+		}
+	}
+	return method
+}
+
 // parse parses the code and generates each function code and any other needed info for final code.
-func (g *Generator) parse() {
-	myType := Type{name: g.typeName, pkgPath: g.pkgPath}
-	obj := g.findObj(myType)
-	g.parseTypeDef(myType, obj)
+// It returns all the problems found.
+func (g *Generator) parse() error {
+	pkgPaths, err := g.load(g.pkgPath)
+	if err != nil {
+		g.errs.add(err)
+		return g.errs
+	}
+	switch g.options.Unexported {
+	case "", UnexportedSkip, UnexportedReflect:
+	default:
+		g.errs.add(fmt.Errorf("unknown strategy %s for fields that are not exported, it can be %s or %s", g.options.Unexported, UnexportedSkip, UnexportedReflect))
+		return g.errs
+	}
+	objs := g.getRootTypes(pkgPaths)
+	if len(g.errs) > 0 {
+		return g.errs
+	}
+	for _, pkgPath := range pkgPaths {
+		if g.options.Out == "" && g.isForeign(pkgPath) {
+			g.errs.add(fmt.Errorf("package %s is not in the main module, its functions can be generated in a package of the main module with the Out option", pkgPath))
+		}
+	}
+	if len(g.errs) > 0 {
+		return g.errs
+	}
+	if g.options.Out != "" {
+		if g.outPkgPath, err = g.loadOut(pkgPaths[0]); err != nil {
+			g.errs.add(err)
+			return g.errs
+		}
+		for _, obj := range objs {
+			g.getOutFuncName(obj.Type().(*types.Named))
+		}
+		return g.errs.err()
+	}
+	for _, obj := range objs {
+		// the types reached by the functions of the types before are already generated
+		if myType := (Type{name: obj.Name(), pkgPath: obj.Pkg().Path()}); g.equals[myType] == nil {
+			g.parseTypeDef(myType, obj)
+		}
+	}
+	return g.errs.err()
 }
 
 // findObj finds a go ast node by name in specified package.
-func (g *Generator) findObj(myType Type) types.Object {
-	pkgObj := g.defs[myType.pkgPath]
+func (g *Generator) findObj(myType Type) (types.Object, error) {
+	return g.getPkg(myType.pkgPath).findObj(myType.name)
+}
+
+// getPkg returns the pkg object for the import path, creating it the first time.
+func (g *Generator) getPkg(pkgPath string) *pkg {
+	pkgObj := g.defs[pkgPath]
 	if pkgObj == nil {
-		pkgObj = newPkg(myType.pkgPath, g.input[myType.pkgPath])
-		g.defs[myType.pkgPath] = pkgObj
+		var imp types.Importer
+		if input, isTest := g.input[pkgPath]; isTest {
+			imp = &inputImporter{g: g}
+			pkgObj = newPkg(pkgPath, input, nil, imp)
+			pkgObj.resolver = imp
+		} else {
+			var imports map[string]*packages.Package
+			loaded := g.loaded[pkgPath]
+			if loaded != nil {
+				imports = loaded.Imports
+			}
+			pkgObj = newPkg(pkgPath, nil, loaded, packageImporter(imports))
+			pkgObj.resolver = &loadImporter{g: g}
+		}
+		g.defs[pkgPath] = pkgObj
 	}
-	return pkgObj.findObj(myType.name)
+	return pkgObj
 }
 
 // isPointer returns true if typ is of type pointer.
@@ -312,101 +755,579 @@ func (g *Generator) isPointer(typ types.Type) bool {
 // name is the name of the type.
 // obj is the type object the name refers to.
 // Generates an Equal<name> method which it stores in equals map.
+// Generic types get generic functions, with a comparator parameter for each type parameter not constrained by comparable.
 func (g *Generator) parseTypeDef(myType Type, obj types.Object) {
-	var result bytes.Buffer
-	typ := obj.Type().Underlying()
-	if g.isPointer(typ) {
-		result.WriteString(fmt.Sprintf("func Equal%s(t1, t2 %s) bool {\n", myType.name, myType.name))
-	} else {
-		result.WriteString(fmt.Sprintf("func Equal%s(t1, t2 *%s) bool {\n", myType.name, myType.name))
-		// we artificially introduced pointers here, we need to check for non nil
-		result.WriteString(fmt.Sprintf("if t1 == t2 {\nreturn true\n}\nif t1 == nil || t2 == nil {\nreturn false\n}\n"))
+	named := obj.Type().(*types.Named)
+	if g.isMethod(named) && g.getEqualMethod(named) != nil {
+		g.errorf(obj, "type %s has an Equal method, it can not have a generated one", named)
+	}
+	g.checkExported(myType, named)
+	g.checkStdlib(obj, named.Underlying())
+	g.startCode(myType)
+	if g.options.CycleSafe && g.isCyclic(named) {
+		g.cyclicTypes[myType] = true
+	}
+	g.setChain(myType, named)
+	// the type is qualified if its function is generated in another package, with the Out option
+	typeName := g.getReferenceUpdateImports(named.Obj().Pkg().Path(), named.Obj().Name())
+	typeParams, typeArgs := g.getTypeParams(named)
+	g.parseFunc(myType, named.Underlying(), typeParams, typeName+typeArgs, named.TypeParams(), g.isMethod(named))
+}
+
+// parseInstanceDef parses an instantiated generic type.
+// Generates an Equal<name> function, where name is the name of the instance, in the current package.
+// Unexported fields of a type from another package can not be compared, so they are reported as errors, unless the Unexported option says otherwise.
+func (g *Generator) parseInstanceDef(myType Type, typ *types.Named) {
+	g.checkExported(myType, typ)
+	g.startCode(myType)
+	if g.options.CycleSafe && g.isCyclic(typ) {
+		g.cyclicTypes[myType] = true
+	}
+	g.setChain(myType, typ)
+	g.parseFunc(myType, typ.Underlying(), "", types.TypeString(typ, g.qualifier), nil, false)
+}
+
+// isMethod returns true if we generate an Equal method for the named type.
+// Methods can not be declared on interfaces, pointers, instantiated generic types or types of other packages, with the Out option or of other modules.
+func (g *Generator) isMethod(typ *types.Named) bool {
+	if !g.options.Method || g.options.Out != "" || (typ.TypeArgs().Len() > 0 && !hasTypeParams(typ)) || g.isForeign(typ.Obj().Pkg().Path()) {
+		return false
+	}
+	switch typ.Underlying().(type) {
+	case *types.Interface, *types.Pointer:
+		return false
 	}
+	return true
+}
+
+// startCode stores that we generate an Equal function for myType and makes it the current parsed type.
+func (g *Generator) startCode(myType Type) {
 	// we are storing now that we generate an Equal function so this can not be generated twice
-	code := newCode(myType.name, g.defs[myType.pkgPath])
-	g.equals[myType] = code
+	g.equals[myType] = newCode(myType.name, g.defs[myType.pkgPath])
 	// store what is the current parsed named type
 	g.usedTypes = append(g.usedTypes, myType)
-	result.WriteString(g.parseType(myType.name, typ, true, false))
-	result.WriteString("return true\n}")
-	code.code = result.String()
+}
+
+// parseFunc generates the Equal function for the current parsed type, which has the underlying type typ.
+// typeName is how the type is referred in the current package.
+// If isMethod, it generates an Equal method, the receiver being t1.
+// tparams are the type parameters of a generic type, each one needs a comparator parameter if not constrained by comparable.
+// If Diff, Hash or Clone functions are requested, they are generated in the same code.
+func (g *Generator) parseFunc(myType Type, typ types.Type, typeParams, typeName string, tparams *types.TypeParamList, isMethod bool) {
+	var result bytes.Buffer
+	isDiff, isHash, isClone := g.diff, g.hash, g.clone
+	g.diff, g.hash, g.clone = false, false, false
+	// the tag of the field we came from is not used by the type of the field
+	tag := g.tag
+	g.tag = fieldTag{}
+	result.WriteString(g.parseFuncBody(myType, typ, typeParams, typeName, g.getComparatorParams(tparams), isMethod))
+	if g.options.Diff {
+		g.diff = true
+		result.WriteString("\n\n")
+		result.WriteString(g.parseFuncBody(myType, typ, typeParams, typeName, g.getComparatorParams(tparams), isMethod))
+		g.diff = false
+	}
+	if g.options.Hash {
+		g.hash = true
+		result.WriteString("\n\n")
+		result.WriteString(g.parseHashFuncBody(myType, typ, typeParams, typeName, g.getComparatorParams(tparams), isMethod))
+		g.hash = false
+	}
+	if g.options.Clone {
+		g.clone = true
+		result.WriteString("\n\n")
+		result.WriteString(g.parseCloneFuncBody(myType, typ, typeParams, typeName, g.getComparatorParams(tparams), isMethod))
+	}
+	// helpers of types of other modules are private, their types are registered by the functions of their own packages
+	if g.options.Register && tparams.Len() == 0 && !g.thirdParty[myType] {
+		result.WriteString(g.getRegistration(myType, typ, typeName, isMethod))
+	}
+	g.diff, g.hash, g.clone = isDiff, isHash, isClone
+	g.tag = tag
+	g.equals[myType].code = result.String()
 	g.equalsOrder = append(g.equalsOrder, myType)
 	g.usedTypes = g.usedTypes[:len(g.usedTypes)-1]
 }
 
-// getArgs returns args used to call Equal functions and whether the args are dereferenced.
-func (g *Generator) getArgs(name1, name2 string, isPointer, isPointerReference bool) (string, string, bool) {
-	isDereferenced := false
-	if isPointer {
-		if isPointerReference {
-			name1 = fmt.Sprintf("(*%s)", name1)
-			name2 = fmt.Sprintf("(*%s)", name2)
-			isDereferenced = true
-		}
-	} else {
-		if !isPointerReference {
-			name1 = fmt.Sprintf("(&%s)", name1)
-			name2 = fmt.Sprintf("(&%s)", name2)
-		}
+// getRegistration returns an init function registering the Equal function of a type, so equal.Interface compares its values with it.
+// Interfaces are not dynamic types of interface values, so they are not registered.
+func (g *Generator) getRegistration(myType Type, typ types.Type, typeName string, isMethod bool) string {
+	if _, ok := typ.(*types.Interface); ok {
+		return ""
 	}
-	return name1, name2, isDereferenced
+	register, funcName := "Register", g.getTypeFuncName("Equal", myType)
+	if g.isPointer(typ) {
+		register = "RegisterValue"
+	}
+	if isMethod && g.isPointer(typ) {
+		funcName = typeName + ".Equal"
+	} else if isMethod {
+		funcName = "(*" + typeName + ").Equal"
+	}
+	return fmt.Sprintf("\n\nfunc init() {\n%s(%s)\n}", g.getReferenceUpdateImports(equalPkgPath, register), funcName)
 }
 
-// getReferenceUpdateImports returns the fully qualified name relative to pkgPath.
-// If the referenced package name is not empty it will update imports for the current code.
-func (g *Generator) getReferenceUpdateImports(pkgPath, name string) string {
-	// getReferencedPackageName returns the referenced package name in current package
-	// returns empty string if the package is the current package
-	var getReferencedPackageName = func(pkgPath string) string {
-		currentType := g.usedTypes[len(g.usedTypes)-1]
-		currentPackagePath := currentType.pkgPath
-		if pkgPath == currentPackagePath {
-			return ""
+// getFuncHeader returns the header of an Equal or a Diff function, or method, named funcName.
+// Types with an underlying pointer kind, like maps and slices, are passed by value, other types by pointer.
+func (g *Generator) getFuncHeader(typ types.Type, typeParams, typeName, funcName, params, returnType string, isMethod bool) string {
+	pointer := "*"
+	if g.isPointer(typ) {
+		pointer = ""
+	}
+	if isMethod {
+		return fmt.Sprintf("func (t1 %s%s) %s(t2 %s%s%s) %s {\n", pointer, typeName, funcName, pointer, typeName, params, returnType)
+	}
+	return fmt.Sprintf("func %s%s(t1, t2 %s%s%s) %s {\n", funcName, typeParams, pointer, typeName, params, returnType)
+}
+
+// parseFuncBody returns the Equal or the Diff function for the current parsed type.
+func (g *Generator) parseFuncBody(myType Type, typ types.Type, typeParams, typeName, comparators string, isMethod bool) string {
+	var result bytes.Buffer
+	operation, returnType, same, different, end := "Equal", "bool", "true", "false", "true"
+	if g.diff {
+		differences := g.getReferenceUpdateImports(equalPkgPath, "Difference")
+		operation, returnType, same, end = "Diff", "[]"+differences, "nil", "diffs"
+		if !g.isPointer(typ) {
+			different = fmt.Sprintf("[]%s{{Value1: %s, Value2: %s, Reason: %s}}",
+				differences, g.getFormatted("t1"), g.getFormatted("t2"), g.getReferenceUpdateImports(equalPkgPath, "NilValue"))
 		}
-		referencePackageName := g.defs[currentPackagePath].imports[pkgPath]
-		if referencePackageName == "" {
-			// this can happen when using custom configuration
-			pkgObj := g.defs[pkgPath]
-			if pkgObj == nil {
-				// if the referenced package has not been processed yet
-				_, referencePackageName = g.buildDefault(pkgPath)
-			} else {
-				referencePackageName = pkgObj.name
-			}
+	}
+	chain := g.chains[myType]
+	var chainPath string
+	if chain != nil && g.diff {
+		// the differences of every walked value are relative to the first value
+		chainPath = fmt.Sprintf("%s(%q, depth)", g.getReferenceUpdateImports(equalPkgPath, "ChainPath"), chain.Name())
+		same, different = "chain", fmt.Sprintf("%s(chain, %s, %s)", g.getReferenceUpdateImports(equalPkgPath, "Nested"), chainPath, different)
+	}
+	// we artificially introduced pointers for types that are not pointers, we need to check for non nil
+	nilCheck := fmt.Sprintf("if t1 == t2 {\nreturn %s\n}\nif t1 == nil || t2 == nil {\nreturn %s\n}\n", same, different)
+	if g.options.Semantic {
+		nilCheck = fmt.Sprintf("if t1 == t2 {\nreturn %s\n}\n%s%s", same, getZeroCheck("t1", typeName), getZeroCheck("t2", typeName))
+	}
+	funcName, params := g.getTypeFuncName(operation, myType), comparators
+	if isMethod {
+		funcName = operation
+	}
+	if g.cyclicTypes[myType] {
+		// values can form cycles, so the function compares them with a new set of visited pairs
+		// cyclic types have no type parameters, so there are no comparators
+		visited := g.getReferenceUpdateImports(equalPkgPath, "Visited")
+		call := fmt.Sprintf("%s(t1, t2, %s{})", getVisitedName(funcName), visited)
+		if isMethod {
+			call = fmt.Sprintf("t1.%s(t2, %s{})", getVisitedName(funcName), visited)
 		}
-		return referencePackageName
+		result.WriteString(g.getFuncHeader(typ, typeParams, typeName, funcName, params, returnType, isMethod))
+		result.WriteString(fmt.Sprintf("return %s\n}\n\n", call))
+		funcName, params = getVisitedName(funcName), params+", visited "+visited
 	}
-	referencedPackageName := getReferencedPackageName(pkgPath)
-	if referencedPackageName == "" {
-		return name
+	result.WriteString(g.getFuncHeader(typ, typeParams, typeName, funcName, params, returnType, isMethod))
+	if chain != nil && g.diff {
+		result.WriteString(fmt.Sprintf("var chain %s\nfor depth := 0; ; depth++ {\n", returnType))
+	} else if chain != nil {
+		result.WriteString("for {\n")
 	}
-	code := g.equals[g.usedTypes[len(g.usedTypes)-1]]
-	code.imports[pkgPath] = struct{}{}
-	if referencedPackageName == "." {
-		return name
+	if !g.isPointer(typ) {
+		result.WriteString(nilCheck)
 	}
-	return referencedPackageName + "." + name
+	if g.cyclicTypes[myType] {
+		// a pair compared again is equal, as its comparison is in progress
+		result.WriteString(fmt.Sprintf("if visited.Visit(t1, t2) {\nreturn %s\n}\n", same))
+	}
+	if g.diff {
+		result.WriteString(fmt.Sprintf("var diffs %s\n", returnType))
+	}
+	result.WriteString(g.parseBody(myType, typ))
+	if chain == nil {
+		result.WriteString(fmt.Sprintf("return %s\n}", end))
+		return result.String()
+	}
+	// the loop continues with the values linked by the chain field, it ends at a nil or a different value
+	if g.diff {
+		result.WriteString(fmt.Sprintf("if len(diffs) > 0 {\nchain = %s(chain, %s, diffs)\n}\n", g.getReferenceUpdateImports(equalPkgPath, "Nested"), chainPath))
+	}
+	result.WriteString(fmt.Sprintf("t1, t2 = t1.%s, t2.%s\n}\n}", chain.Name(), chain.Name()))
+	return result.String()
 }
 
-// parseType parses a types.Type
-// name is the name of a variable of type typ or the name of a type with underlying type typ.
-func (g *Generator) parseType(name string, typ types.Type, isType bool, isPointerReference bool) string {
-	// check for custom type
-	if ok, customCall := g.getEqualFunctionName(typ); ok {
-		if customCall == "" {
-			return ""
-		}
-		name1, name2 := getNames(name, isType)
-		return fmt.Sprintf("if !%s(%s, %s) {\n return false\n}\n", customCall, name1, name2)
+// parseHashFuncBody returns the Hash function for the current parsed type.
+// Values that are equal according to the Equal function write the same bytes to the hash.
+func (g *Generator) parseHashFuncBody(myType Type, typ types.Type, typeParams, typeName, comparators string, isMethod bool) string {
+	var result bytes.Buffer
+	hash := g.getReferenceUpdateImports("hash/maphash", "Hash")
+	if isMethod && g.isPointer(typ) {
+		result.WriteString(fmt.Sprintf("func (t %s) Hash(h *%s%s) {\n", typeName, hash, comparators))
+	} else if isMethod {
+		result.WriteString(fmt.Sprintf("func (t *%s) Hash(h *%s%s) {\n", typeName, hash, comparators))
+	} else if g.isPointer(typ) {
+		result.WriteString(fmt.Sprintf("func %s%s(t %s, h *%s%s) {\n", g.getTypeFuncName("Hash", myType), typeParams, typeName, hash, comparators))
+	} else {
+		result.WriteString(fmt.Sprintf("func %s%s(t *%s, h *%s%s) {\n", g.getTypeFuncName("Hash", myType), typeParams, typeName, hash, comparators))
 	}
-	switch t := typ.(type) {
-	case *types.Named:
-		return g.parseNamed(name, t, isType, isPointerReference)
+	if !g.isPointer(typ) && g.options.Semantic {
+		// we artificially introduced pointers here, nil is hashed as a zero value
+		result.WriteString(getZeroCheck("t", typeName))
+	} else if !g.isPointer(typ) {
+		// we artificially introduced pointers here, nil is hashed apart from any value
+		result.WriteString("if t == nil {\nh.WriteByte(0)\nreturn\n}\nh.WriteByte(1)\n")
+	}
+	result.WriteString(g.parseBody(myType, typ))
+	result.WriteString("}")
+	return result.String()
+}
+
+// parseCloneFuncBody returns the Clone function for the current parsed type.
+// The copy has the values compared by the Equal function, the values it ignores are not copied.
+func (g *Generator) parseCloneFuncBody(myType Type, typ types.Type, typeParams, typeName, comparators string, isMethod bool) string {
+	var result bytes.Buffer
+	if isMethod && g.isPointer(typ) {
+		result.WriteString(fmt.Sprintf("func (src %s) Clone(%s) %s {\n", typeName, strings.TrimPrefix(comparators, ", "), typeName))
+	} else if isMethod {
+		result.WriteString(fmt.Sprintf("func (src *%s) Clone(%s) *%s {\n", typeName, strings.TrimPrefix(comparators, ", "), typeName))
+	} else if g.isPointer(typ) {
+		result.WriteString(fmt.Sprintf("func %s%s(src %s%s) %s {\n", g.getTypeFuncName("Clone", myType), typeParams, typeName, comparators, typeName))
+	} else {
+		result.WriteString(fmt.Sprintf("func %s%s(src *%s%s) *%s {\n", g.getTypeFuncName("Clone", myType), typeParams, typeName, comparators, typeName))
+	}
+	if g.isPointer(typ) {
+		result.WriteString(fmt.Sprintf("var dst %s\n", typeName))
+	} else {
+		// we artificially introduced pointers here, the copy of nil is nil
+		result.WriteString(fmt.Sprintf("if src == nil {\nreturn nil\n}\ndst := new(%s)\n", typeName))
+	}
+	result.WriteString(g.parseBody(myType, typ))
+	result.WriteString("return dst\n}")
+	return result.String()
+}
+
+// getOperation returns the prefix of the generated functions: Equal, Diff, Hash or Clone.
+func (g *Generator) getOperation() string {
+	if g.diff {
+		return "Diff"
+	}
+	if g.hash {
+		return "Hash"
+	}
+	if g.clone {
+		return "Clone"
+	}
+	return "Equal"
+}
+
+// getWrite returns code writing value, which is comparable, to hasher.
+func (g *Generator) getWrite(hasher, value string) string {
+	return fmt.Sprintf("%s(%s, %s)\n", g.getReferenceUpdateImports("hash/maphash", "WriteComparable"), hasher, value)
+}
+
+// getNilCheck returns code hashing a pointer named name: nil is hashed apart from any value, next hashes the value.
+func getNilCheck(hasher, name, next string) string {
+	return fmt.Sprintf("if %s == nil {\n%s.WriteByte(0)\n} else {\n%s.WriteByte(1)\n%s}\n", name, hasher, hasher, next)
+}
+
+// getZeroCheck returns code replacing the nil pointer name with a pointer to a zero value of typeName, in semantic mode.
+func getZeroCheck(name, typeName string) string {
+	return fmt.Sprintf("if %s == nil {\n%s = new(%s)\n}\n", name, name, typeName)
+}
+
+// isSemantic returns true if a nil pointer is equal to a pointer to a zero value in the current code:
+// for every pointer in semantic mode, or for the pointers of a field with the goequal:"semantic" tag.
+func (g *Generator) isSemantic() bool {
+	return g.options.Semantic || g.tag.semantic
+}
+
+// getOrZero returns a go expression evaluating to the pointer name, or to a pointer to a zero value if it is nil.
+func (g *Generator) getOrZero(name string) string {
+	return fmt.Sprintf("%s(%s)", g.getReferenceUpdateImports(equalPkgPath, "OrZero"), name)
+}
+
+// getFailure returns the code executed when two values are found different.
+// Equal functions return false, Diff functions record the difference for the path of name.
+// value1 and value2 are the values reported as different, empty for missing values.
+func (g *Generator) getFailure(name string, isType bool, reason, value1, value2 string) string {
+	if !g.diff {
+		return "return false\n"
+	}
+	return fmt.Sprintf("diffs = append(diffs, %s{Path: %s, Value1: %s, Value2: %s, Reason: %s})\n",
+		g.getReferenceUpdateImports(equalPkgPath, "Difference"), g.getPath(name, isType),
+		g.getFormatted(value1), g.getFormatted(value2), g.getReferenceUpdateImports(equalPkgPath, reason))
+}
+
+// getCheck returns code that fails if cond is true and otherwise continues with next.
+// Diff functions don't stop at the first difference, so next is executed only if there is no failure.
+func (g *Generator) getCheck(cond, failure, next string) string {
+	if g.diff && next != "" {
+		return fmt.Sprintf("if %s {\n%s} else {\n%s}\n", cond, failure, next)
+	}
+	return fmt.Sprintf("if %s {\n%s}\n%s", cond, failure, next)
+}
+
+// getFormatted returns a go expression formatting the value, empty string for missing values.
+func (g *Generator) getFormatted(value string) string {
+	if value == "" {
+		return `""`
+	}
+	return fmt.Sprintf("%s(%s)", g.getReferenceUpdateImports("fmt", "Sprint"), value)
+}
+
+// getPath returns a go expression evaluating to the path of the value named name, as reported by Diff functions.
+// e.g.: (*a[i1])[key1] -> fmt.Sprintf("a[%d][%#v]", i1, key1)
+func (g *Generator) getPath(name string, isType bool) string {
+	name = strings.NewReplacer("(*", "", ")", "").Replace(name)
+	if isType {
+		// the type is the compared value, its name is not part of the path
+		name = strings.TrimLeftFunc(name, func(r rune) bool {
+			return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_'
+		})
+	}
+	var format bytes.Buffer
+	var args []string
+	for {
+		start := strings.Index(name, "[")
+		if start == -1 {
+			format.WriteString(name)
+			break
+		}
+		end := start + strings.Index(name[start:], "]")
+		index := name[start+1 : end]
+		format.WriteString(name[:start])
+		if strings.HasPrefix(index, "key") {
+			format.WriteString("[%#v]")
+		} else {
+			format.WriteString("[%d]")
+		}
+		args = append(args, index)
+		name = name[end+1:]
+	}
+	if len(args) == 0 {
+		return strconv.Quote(format.String())
+	}
+	return fmt.Sprintf("%s(%s, %s)", g.getReferenceUpdateImports("fmt", "Sprintf"), strconv.Quote(format.String()), strings.Join(args, ", "))
+}
+
+// getTypeParams returns, for a generic type, the type parameters declaration and the type arguments used to refer the type.
+// e.g.: for type Page[T any, K comparable]: [T any, K comparable], [T, K]
+func (g *Generator) getTypeParams(named *types.Named) (string, string) {
+	typeParams := named.TypeParams()
+	if typeParams.Len() == 0 {
+		return "", ""
+	}
+	decls := make([]string, typeParams.Len())
+	args := make([]string, typeParams.Len())
+	for i := 0; i < typeParams.Len(); i++ {
+		typeParam := typeParams.At(i)
+		name := typeParam.Obj().Name()
+		decls[i] = name + " " + types.TypeString(typeParam.Constraint(), g.qualifier)
+		args[i] = name
+	}
+	return "[" + strings.Join(decls, ", ") + "]", "[" + strings.Join(args, ", ") + "]"
+}
+
+// getComparatorParams returns the comparator parameters of the function generated for a generic type.
+// e.g.: for type Page[T any, K comparable]: ", eqT func(a, b T) bool",
+// ", hashT func(*maphash.Hash, T)" for Hash functions or ", cloneT func(T) T" for Clone functions
+func (g *Generator) getComparatorParams(typeParams *types.TypeParamList) string {
+	var comparators bytes.Buffer
+	for i := 0; i < typeParams.Len(); i++ {
+		typeParam := typeParams.At(i)
+		if isComparable(typeParam) {
+			continue
+		}
+		name := typeParam.Obj().Name()
+		if g.hash {
+			comparators.WriteString(fmt.Sprintf(", hash%s func(*%s, %s)", name, g.getReferenceUpdateImports("hash/maphash", "Hash"), name))
+		} else if g.clone {
+			comparators.WriteString(fmt.Sprintf(", clone%s func(%s) %s", name, name, name))
+		} else {
+			comparators.WriteString(fmt.Sprintf(", eq%s func(a, b %s) bool", name, name))
+		}
+	}
+	return comparators.String()
+}
+
+// getComparators returns the comparators passed to the Equal, Diff or Hash function of a generic type, for its type arguments.
+func (g *Generator) getComparators(named *types.Named) string {
+	var result bytes.Buffer
+	typeParams, typeArgs := named.TypeParams(), named.TypeArgs()
+	for i := 0; i < typeParams.Len() && i < typeArgs.Len(); i++ {
+		if !isComparable(typeParams.At(i)) {
+			result.WriteString(", " + g.getComparator(typeArgs.At(i)))
+		}
+	}
+	return result.String()
+}
+
+// getComparator returns a function value used to compare two values of typ.
+// A type parameter of the current generic type already has its comparator, otherwise we create a function literal.
+// Hash functions get a function hashing a value of typ and Clone functions a function copying it.
+func (g *Generator) getComparator(typ types.Type) string {
+	prefix := "eq"
+	if g.hash {
+		prefix = "hash"
+	}
+	if g.clone {
+		prefix = "clone"
+	}
+	if typeParam, ok := typ.(*types.TypeParam); ok && !isComparable(typeParam) {
+		return prefix + typeParam.Obj().Name()
+	}
+	// arrays are compared by value, so we don't want parseArray to dereference them
+	_, isArray := typ.(*types.Array)
+	if g.hash {
+		return fmt.Sprintf("func(h *%s, t %s) {\n%s}",
+			g.getReferenceUpdateImports("hash/maphash", "Hash"), types.TypeString(typ, g.qualifier), g.parseType("v", typ, true, isArray))
+	}
+	if g.clone {
+		typeName := types.TypeString(typ, g.qualifier)
+		return fmt.Sprintf("func(src %s) %s {\nvar dst %s\n%sreturn dst\n}", typeName, typeName, typeName, g.parseType("v", typ, true, isArray))
+	}
+	// comparators are used by Diff functions too, but they always compare for equality
+	isDiff := g.diff
+	g.diff = false
+	defer func() { g.diff = isDiff }()
+	return fmt.Sprintf("func(t1, t2 %s) bool {\n%sreturn true\n}", types.TypeString(typ, g.qualifier), g.parseType("v", typ, true, isArray))
+}
+
+// isComparable returns true if values of the type parameter can be compared with ==, because of its constraint.
+func isComparable(typeParam *types.TypeParam) bool {
+	iface, ok := typeParam.Constraint().Underlying().(*types.Interface)
+	return ok && iface.IsComparable()
+}
+
+// getArgs returns args used to call Equal functions and whether the args are dereferenced.
+func (g *Generator) getArgs(name1, name2 string, isPointer, isPointerReference bool) (string, string, bool) {
+	isDereferenced := false
+	if isPointer {
+		if isPointerReference {
+			name1 = fmt.Sprintf("(*%s)", name1)
+			name2 = fmt.Sprintf("(*%s)", name2)
+			isDereferenced = true
+		}
+	} else {
+		if !isPointerReference {
+			name1 = fmt.Sprintf("(&%s)", name1)
+			name2 = fmt.Sprintf("(&%s)", name2)
+		}
+	}
+	return name1, name2, isDereferenced
+}
+
+// qualifier is a types.Qualifier for types referred from the current package.
+func (g *Generator) qualifier(pkg *types.Package) string {
+	return strings.TrimSuffix(g.getReferenceUpdateImports(pkg.Path(), ""), ".")
+}
+
+// getReferenceUpdateImports returns the fully qualified name relative to pkgPath.
+// If the referenced package name is not empty it will update imports for the current code.
+func (g *Generator) getReferenceUpdateImports(pkgPath, name string) string {
+	// getReferencedPackageName returns the referenced package name in current package
+	// returns empty string if the package is the current package
+	var getReferencedPackageName = func(pkgPath string) string {
+		currentType := g.usedTypes[len(g.usedTypes)-1]
+		currentPackagePath := currentType.pkgPath
+		if pkgPath == currentPackagePath {
+			return ""
+		}
+		referencePackageName := g.defs[currentPackagePath].imports[pkgPath]
+		if referencePackageName == "" {
+			// this can happen when using custom configuration
+			pkgObj := g.defs[pkgPath]
+			if pkgPath == equalPkgPath {
+				// this package doesn't need to be a dependency of the processed packages
+				referencePackageName = "equal"
+			} else if pkgObj == nil {
+				// if the referenced package has not been processed yet
+				var err error
+				if _, referencePackageName, err = g.findPackage(pkgPath); err != nil {
+					g.errs.add(err)
+				}
+			} else {
+				referencePackageName = pkgObj.name
+			}
+			if currentPackagePath == g.outPkgPath {
+				// the output package can import packages with the same name
+				referencePackageName = g.getOutImportName(pkgPath, referencePackageName)
+			}
+		}
+		return referencePackageName
+	}
+	referencedPackageName := getReferencedPackageName(pkgPath)
+	if referencedPackageName == "" {
+		return name
+	}
+	code := g.equals[g.usedTypes[len(g.usedTypes)-1]]
+	code.imports[pkgPath] = struct{}{}
+	if referencedPackageName == "." {
+		return name
+	}
+	return referencedPackageName + "." + name
+}
+
+// parseType parses a types.Type
+// name is the name of a variable of type typ or the name of a type with underlying type typ.
+func (g *Generator) parseType(name string, typ types.Type, isType bool, isPointerReference bool) string {
+	// aliases are compared as the types they stand for, e.g.: json.RawMessage is jsontext.Value
+	typ = types.Unalias(typ)
+	// check for custom type
+	if ok, customCall := g.getEqualFunctionName(typ); ok {
+		if customCall == "" {
+			return ""
+		}
+		if g.clone {
+			src, dst := getCloneNames(name, isType)
+			return fmt.Sprintf("%s = %s\n", dst, src)
+		}
+		if iface, ok := typ.Underlying().(*types.Interface); ok && g.options.Dispatch {
+			return g.parseInterface(name, iface, isType, customCall)
+		}
+		name1, name2 := getNames(name, isType)
+		return g.getCheck(fmt.Sprintf("!%s(%s, %s)", customCall, name1, name2), g.getFailure(name, isType, "DifferentValues", name1, name2), "")
+	}
+	switch t := typ.(type) {
+	case *types.Named:
+		if g.isInlined(t) {
+			return g.parseType(name, t.Underlying(), isType, isPointerReference)
+		}
+		if method := g.getEqualMethod(t); method != nil {
+			return g.parseEqualMethod(name, t, method, isType)
+		}
+		if comparison, ok := g.getStdlibComparison(t); ok {
+			return g.parseStdlib(name, comparison, isType)
+		}
+		return g.parseNamed(name, t, isType, isPointerReference)
 	case *types.Struct:
 		return g.parseStruct(t)
 	case *types.Basic:
+		if g.hash && g.isSpecialFloat(t) {
+			return g.getFloatHash(t, getHasher(name), getName(name, isType))
+		}
+		if g.hash {
+			return g.getWrite(getHasher(name), getName(name, isType))
+		}
+		if g.clone {
+			src, dst := getCloneNames(name, isType)
+			return fmt.Sprintf("%s = %s\n", dst, src)
+		}
 		name1, name2 := getNames(name, isType)
-		return fmt.Sprintf("if %s != %s {\nreturn false\n}\n", name1, name2)
+		return g.getCheck(g.getDifferent(t, name1, name2), g.getFailure(name, isType, "DifferentValues", name1, name2), "")
+	case *types.TypeParam:
+		if g.hash && isComparable(t) {
+			return g.getWrite(getHasher(name), getName(name, isType))
+		}
+		if g.hash {
+			return fmt.Sprintf("hash%s(%s, %s)\n", t.Obj().Name(), getHasher(name), getName(name, isType))
+		}
+		if g.clone {
+			src, dst := getCloneNames(name, isType)
+			if isComparable(t) {
+				return fmt.Sprintf("%s = %s\n", dst, src)
+			}
+			return fmt.Sprintf("%s = clone%s(%s)\n", dst, t.Obj().Name(), src)
+		}
+		name1, name2 := getNames(name, isType)
+		failure := g.getFailure(name, isType, "DifferentValues", name1, name2)
+		if isComparable(t) {
+			return g.getCheck(fmt.Sprintf("%s != %s", name1, name2), failure, "")
+		}
+		return g.getCheck(fmt.Sprintf("!eq%s(%s, %s)", t.Obj().Name(), name1, name2), failure, "")
 	case *types.Slice:
 		return g.parseSlice(name, t, isType)
 	case *types.Array:
@@ -419,64 +1340,684 @@ func (g *Generator) parseType(name string, typ types.Type, isType bool, isPointe
 	return ""
 }
 
+// isSpecialFloat returns true if typ is a floating-point or complex number not compared with ==,
+// because it has a tolerance or because NaN values are equal.
+func (g *Generator) isSpecialFloat(typ types.Type) bool {
+	basic, ok := typ.(*types.Basic)
+	if !ok || basic.Info()&(types.IsFloat|types.IsComplex) == 0 {
+		return false
+	}
+	return g.options.NaNEqual || g.tag.hasTolerance()
+}
+
+// getDifferent returns the condition of two basic values being different.
+// Complex numbers are different if their real or their imaginary parts are different.
+func (g *Generator) getDifferent(typ *types.Basic, name1, name2 string) string {
+	if !g.isSpecialFloat(typ) {
+		return fmt.Sprintf("%s != %s", name1, name2)
+	}
+	if typ.Info()&types.IsComplex != 0 {
+		return fmt.Sprintf("(%s) || (%s)",
+			g.getFloatDifferent("real("+name1+")", "real("+name2+")"), g.getFloatDifferent("imag("+name1+")", "imag("+name2+")"))
+	}
+	return g.getFloatDifferent(name1, name2)
+}
+
+// getFloatDifferent returns the condition of two floating-point numbers being different:
+// they are not equal, not within any tolerance of the current field and not both NaN, if NaN values are equal.
+func (g *Generator) getFloatDifferent(name1, name2 string) string {
+	cond := fmt.Sprintf("%s != %s", name1, name2)
+	for _, tolerance := range [][2]string{{"AbsEqual", g.tag.abs}, {"RelEqual", g.tag.rel}, {"ULPsEqual", g.tag.ulps}} {
+		if tolerance[1] != "" {
+			cond += fmt.Sprintf(" && !%s(%s, %s, %s)", g.getReferenceUpdateImports(equalPkgPath, tolerance[0]), name1, name2, tolerance[1])
+		}
+	}
+	if g.options.NaNEqual {
+		cond += fmt.Sprintf(" && !%s(%s, %s)", g.getReferenceUpdateImports(equalPkgPath, "BothNaN"), name1, name2)
+	}
+	return cond
+}
+
+// getFloatHash returns code writing a floating-point or complex number to hasher, with the same hash for every NaN.
+func (g *Generator) getFloatHash(typ *types.Basic, hasher, name string) string {
+	hashFloat := g.getReferenceUpdateImports(equalPkgPath, "HashFloat")
+	if typ.Info()&types.IsComplex != 0 {
+		return fmt.Sprintf("%s(%s, real(%s))\n%s(%s, imag(%s))\n", hashFloat, hasher, name, hashFloat, hasher, name)
+	}
+	return fmt.Sprintf("%s(%s, %s)\n", hashFloat, hasher, name)
+}
+
+// isInlined returns true if the named type is compared by the current code instead of its own function.
+// Named slices, maps and pointers of a field compared regardless of order are inlined, as their functions keep the order.
+// Named types of a field with a tolerance or with the semantic tag are inlined too, except structs, as their functions don't have them.
+func (g *Generator) isInlined(typ types.Type) bool {
+	named, ok := types.Unalias(typ).(*types.Named)
+	if !ok {
+		return false
+	}
+	switch named.Underlying().(type) {
+	case *types.Slice, *types.Map, *types.Pointer:
+		return g.tag.unordered || g.tag.key != "" || g.tag.hasTolerance() || g.tag.semantic
+	case *types.Array:
+		return g.tag.hasTolerance() || g.tag.semantic
+	case *types.Basic:
+		// named basic types from standard library have no functions
+		isGOROOT, _ := g.isGOROOT(named.Obj())
+		return g.tag.hasTolerance() || isGOROOT
+	}
+	return false
+}
+
 // parseNamed parses a named type.
 // It calls parseTypeDef for parsing the new type def and returns the call to the newly generated function.
+// Instantiated generic types, e.g.: cache.Entry[string, *User], get their own function in the current package.
 func (g *Generator) parseNamed(name string, typ *types.Named, isType bool, isPointerReference bool) string {
 	name1, name2 := getNames(name, isType)
-	typeDecl := typ.Obj()
-	myType := Type{name: typeDecl.Name(), pkgPath: typeDecl.Pkg().Path()}
-	myCode := g.equals[myType]
-	if myCode == nil {
-		// findObj is guarenteed to succeed
-		g.parseTypeDef(myType, g.findObj(myType))
-	}
-	funcName := g.getReferenceUpdateImports(myType.pkgPath, "Equal"+myType.name)
-	// deal with pointer reference, findObj is guarenteed to succeed
-	isPointer := g.isPointer(g.findObj(myType).Type().Underlying())
+	var funcName, comparators string
+	var ok bool
+	if typ.TypeArgs().Len() > 0 && !hasTypeParams(typ) {
+		if funcName, ok = g.parseInstance(typ); !ok {
+			return ""
+		}
+	} else {
+		if funcName, ok = g.getFuncName(typ); !ok {
+			return ""
+		}
+		comparators = g.getComparators(typ)
+	}
+	// deal with pointer reference
+	isPointer := g.isPointer(typ.Underlying())
+	if g.hash {
+		return g.parseNamedHash(name, typ, isType, isPointerReference, funcName, comparators)
+	}
+	if g.clone {
+		return g.parseNamedClone(name, typ, isType, isPointerReference, funcName, comparators)
+	}
+	semantic := isPointerReference && g.isSemantic()
+	if semantic {
+		// a nil pointer is compared as a pointer to a zero value, so it needs no nil check
+		name1, name2 = g.getOrZero(name1), g.getOrZero(name2)
+	}
 	callName1, callName2, isDereferenced := g.getArgs(name1, name2, isPointer, isPointerReference)
+	isDereferenced = isDereferenced && !semantic
+	operation, visited := g.getOperation(), ""
+	if g.passesVisited(typ) {
+		// the function of a cyclic type gets the pairs visited by the current function
+		operation, visited = getVisitedName(operation), ", visited"
+		if !g.isMethod(typ) {
+			funcName = getVisitedName(funcName)
+		}
+	}
 	// if isDereferenced we have to check for non nil before we pass the args to func
-	funcCall := fmt.Sprintf("if !%s(%s, %s) {\nreturn false\n}\n", funcName, callName1, callName2)
+	call := fmt.Sprintf("%s(%s, %s%s%s)", funcName, callName1, callName2, comparators, visited)
+	if g.isMethod(typ) {
+		// the receiver is addressable, so we don't take its address for methods with pointer receivers
+		if !isPointer && !isPointerReference {
+			callName1, callName2 = name1, "&"+name2
+		}
+		// a named pointer type has no methods, e.g.: type P *Y, so we convert it to *Y
+		if isType && isPointerReference && token.IsIdentifier(name) {
+			callName1 = fmt.Sprintf("(%s)(%s)", types.TypeString(types.NewPointer(typ), g.qualifier), callName1)
+		}
+		call = fmt.Sprintf("%s.%s(%s%s%s)", callName1, operation, callName2, comparators, visited)
+	}
+	funcCall := fmt.Sprintf("if !%s {\nreturn false\n}\n", call)
+	if g.diff {
+		// differences found by the called function are relative to the compared values
+		funcCall = fmt.Sprintf("diffs = %s(diffs, %s, %s)\n", g.getReferenceUpdateImports(equalPkgPath, "Nested"), g.getPath(name, isType), call)
+	}
 	if isDereferenced {
-		return fmt.Sprintf("if %s != %s{\nif %s == nil || %s == nil {\nreturn false\n}\n%s}\n", name1, name2, name1, name2, funcCall)
+		nilCheck := g.getCheck(fmt.Sprintf("%s == nil || %s == nil", name1, name2), g.getFailure(name, isType, "NilValue", name1, name2), funcCall)
+		return fmt.Sprintf("if %s != %s{\n%s}\n", name1, name2, nilCheck)
 	}
 	return funcCall
 }
 
+// getFuncName generates the function of a named type, if it was not generated yet, and returns its name.
+// The name is empty for types with methods, as methods don't need the package to be imported.
+// It returns false if the type can not be found.
+// With the Out option the function is generated in the output package,
+// and the functions of types of other modules are generated in the current package, as nothing is written outside the main module.
+func (g *Generator) getFuncName(typ *types.Named) (string, bool) {
+	if g.options.Out != "" {
+		return g.getOutFuncName(typ)
+	}
+	if g.isForeign(typ.Obj().Pkg().Path()) {
+		return g.getLocalFuncName(g.usedTypes[len(g.usedTypes)-1].pkgPath, typ, true)
+	}
+	typeDecl := typ.Obj()
+	myType := Type{name: typeDecl.Name(), pkgPath: typeDecl.Pkg().Path()}
+	obj, err := g.findObj(myType)
+	if err != nil {
+		g.errs.add(err)
+		return "", false
+	}
+	if g.equals[myType] == nil {
+		g.parseTypeDef(myType, obj)
+	}
+	if g.isMethod(typ) {
+		return "", true
+	}
+	return g.getReferenceUpdateImports(myType.pkgPath, g.getOperation()+myType.name), true
+}
+
+// parseEqualMethod generates code comparing two values of a named type with its Equal method.
+// Pointers to the type are dereferenced by parsePointer, so name is always a value.
+// Hash functions don't hash the values, as only the method knows which values are equal,
+// and Clone functions copy them by assignment.
+func (g *Generator) parseEqualMethod(name string, typ *types.Named, method *types.Func, isType bool) string {
+	if g.clone {
+		src, dst := getCloneNames(name, isType)
+		return fmt.Sprintf("%s = %s\n", dst, src)
+	}
+	name1, name2 := getNames(name, isType)
+	arg := name2
+	if _, ok := method.Type().(*types.Signature).Params().At(0).Type().(*types.Pointer); ok {
+		arg = "&" + name2
+	}
+	return g.getCheck(fmt.Sprintf("!%s.Equal(%s)", name1, arg), g.getFailure(name, isType, "DifferentValues", name1, name2), "")
+}
+
+// parseNamedHash returns the call to the Hash function funcName, or to the Hash method, of a named type.
+func (g *Generator) parseNamedHash(name string, typ *types.Named, isType, isPointerReference bool, funcName, comparators string) string {
+	name1, hasher := getName(name, isType), getHasher(name)
+	isPointer := g.isPointer(typ.Underlying())
+	semantic := isPointerReference && g.isSemantic()
+	if semantic {
+		// a nil pointer is hashed as a pointer to a zero value
+		name1 = g.getOrZero(name1)
+	}
+	callName, _, isDereferenced := g.getArgs(name1, name1, isPointer, isPointerReference)
+	isDereferenced = isDereferenced && !semantic
+	call := fmt.Sprintf("%s(%s, %s%s)\n", funcName, callName, hasher, comparators)
+	if g.isMethod(typ) {
+		// the receiver is addressable, so we don't take its address for methods with pointer receivers
+		if !isPointer && !isPointerReference {
+			callName = name1
+		}
+		// a named pointer type has no methods, e.g.: type P *Y, so we convert it to *Y
+		if isType && isPointerReference && token.IsIdentifier(name) {
+			callName = fmt.Sprintf("(%s)(%s)", types.TypeString(types.NewPointer(typ), g.qualifier), callName)
+		}
+		call = fmt.Sprintf("%s.Hash(%s%s)\n", callName, hasher, comparators)
+	}
+	if isDereferenced {
+		return getNilCheck(hasher, name1, call)
+	}
+	return call
+}
+
+// parseNamedClone returns the assignment of the copy made by the Clone function funcName, or by the Clone method, of a named type.
+func (g *Generator) parseNamedClone(name string, typ *types.Named, isType, isPointerReference bool, funcName, comparators string) string {
+	src, dst := getCloneNames(name, isType)
+	isPointer := g.isPointer(typ.Underlying())
+	callName, _, isDereferenced := g.getArgs(src, src, isPointer, isPointerReference)
+	call := fmt.Sprintf("%s(%s%s)", funcName, callName, comparators)
+	if g.isMethod(typ) {
+		// the receiver is addressable, so we don't take its address for methods with pointer receivers
+		if !isPointer && !isPointerReference {
+			callName = src
+		}
+		// a named pointer type has no methods, e.g.: type P *Y, so we convert it to *Y
+		if isType && isPointerReference && token.IsIdentifier(name) {
+			callName = fmt.Sprintf("(%s)(%s)", types.TypeString(types.NewPointer(typ), g.qualifier), callName)
+		}
+		call = fmt.Sprintf("%s.Clone(%s)", callName, strings.TrimPrefix(comparators, ", "))
+	}
+	if !isPointer && !isPointerReference {
+		// we copy the value, the Clone function returns a pointer to it
+		call = "*" + call
+	}
+	if isDereferenced {
+		return fmt.Sprintf("if %s != nil {\n%s = new(%s)\n(*%s) = %s\n}\n", src, dst, types.TypeString(typ, g.qualifier), dst, call)
+	}
+	return fmt.Sprintf("%s = %s\n", dst, call)
+}
+
+// parseInstance generates, if not already generated, the Equal function for an instantiated generic type.
+// The function is generated in the current package and returns its name.
+// It returns false if the instance can not be referred from the current package.
+func (g *Generator) parseInstance(typ *types.Named) (string, bool) {
+	pkgPath := g.outPkgPath
+	if pkgPath == "" {
+		pkgPath = g.usedTypes[len(g.usedTypes)-1].pkgPath
+	}
+	return g.getLocalFuncName(pkgPath, typ, false)
+}
+
+// getLocalFuncName generates in the package pkgPath, if it was not generated yet, the function of a named type of another package and returns its name.
+// Types of different packages can have the same name, e.g.: billing.Order and shipping.Order, so they get a number, as instances do.
+// Generic types get generic functions, any other type gets a function as instantiated generic types do.
+// If thirdParty, the type is of another module and its function is a private helper, e.g.: equal_thirdparty_Y.
+// It returns false if the type can not be referred from the package pkgPath.
+func (g *Generator) getLocalFuncName(pkgPath string, typ *types.Named, thirdParty bool) (string, bool) {
+	if obj := unreferable(typ, pkgPath); obj != nil {
+		g.errorf(obj, "type %s is not exported, it can not be compared outside package %s", obj.Name(), obj.Pkg().Path())
+		return "", false
+	}
+	if importPath := typ.Obj().Pkg().Path(); !canImport(pkgPath, importPath) {
+		g.errorf(typ.Obj(), "package %s is internal, it can not be imported by package %s", importPath, pkgPath)
+		return "", false
+	}
+	named, name := typ, getInstanceName(typ)
+	if hasTypeParams(typ) {
+		named = typ.Origin()
+		name = named.Obj().Name()
+	}
+	if thirdParty {
+		name = "thirdparty_" + name
+	}
+	myType, isNew := g.getLocalType(pkgPath, named, name)
+	if isNew {
+		g.thirdParty[myType] = thirdParty
+		if hasTypeParams(typ) {
+			g.parseTypeDef(myType, named.Obj())
+		} else {
+			g.parseInstanceDef(myType, typ)
+		}
+	}
+	return g.getTypeFuncName(g.getOperation(), myType), true
+}
+
+// getTypeFuncName returns the name of the function of myType for the operation, e.g.: EqualY for Equal.
+// The helpers of types of other modules are private, e.g.: equal_thirdparty_Y.
+func (g *Generator) getTypeFuncName(operation string, myType Type) string {
+	if g.thirdParty[myType] {
+		return strings.ToLower(operation) + "_" + myType.name
+	}
+	return operation + myType.name
+}
+
+// getLocalType returns the type of the function generated for typ in the package pkgPath, named after name,
+// and whether the function is new, so it has to be generated.
+func (g *Generator) getLocalType(pkgPath string, typ *types.Named, name string) (Type, bool) {
+	myType := Type{name: name, pkgPath: pkgPath}
+	// two different instances can have the same name, e.g.: Entry[a.User] and Entry[b.User]
+	// the same type can be reached as checked by the loader and as checked by us, e.g.: with many packages, so it is compared by name too
+	for i := 2; g.instances[myType] != nil && !identical(g.instances[myType], typ); i++ {
+		myType.name = fmt.Sprintf("%s_%d", name, i)
+	}
+	if g.instances[myType] != nil {
+		return myType, false
+	}
+	g.instances[myType] = typ
+	return myType, true
+}
+
+// getInstanceName returns the name of an instantiated generic type, usable as a go identifier.
+// e.g.: Entry[string, *User] -> Entry_string_ptrUser
+func getInstanceName(typ *types.Named) string {
+	names := []string{typ.Obj().Name()}
+	for i := 0; i < typ.TypeArgs().Len(); i++ {
+		names = append(names, getTypeArgName(typ.TypeArgs().At(i)))
+	}
+	return strings.Join(names, "_")
+}
+
+// getTypeArgName returns a go identifier describing a type argument.
+func getTypeArgName(typ types.Type) string {
+	switch t := typ.(type) {
+	case *types.Named:
+		if t.TypeArgs().Len() > 0 {
+			return getInstanceName(t)
+		}
+		return t.Obj().Name()
+	case *types.Alias:
+		return getTypeArgName(types.Unalias(t))
+	case *types.Basic:
+		return strings.Replace(t.Name(), ".", "", -1)
+	case *types.Pointer:
+		return "ptr" + getTypeArgName(t.Elem())
+	case *types.Slice:
+		return "slice" + getTypeArgName(t.Elem())
+	case *types.Array:
+		return fmt.Sprintf("array%d%s", t.Len(), getTypeArgName(t.Elem()))
+	case *types.Map:
+		return "map" + getTypeArgName(t.Key()) + "To" + getTypeArgName(t.Elem())
+	case *types.Chan:
+		return "chan" + getTypeArgName(t.Elem())
+	case *types.Interface:
+		if t.Empty() {
+			return "any"
+		}
+		return "interface"
+	case *types.Struct:
+		return "struct"
+	case *types.Signature:
+		return "func"
+	}
+	return "type"
+}
+
+// hasTypeParams returns true if typ refers type parameters, so it is not a concrete type.
+func hasTypeParams(typ types.Type) bool {
+	switch t := typ.(type) {
+	case *types.TypeParam:
+		return true
+	case *types.Named:
+		for i := 0; i < t.TypeArgs().Len(); i++ {
+			if hasTypeParams(t.TypeArgs().At(i)) {
+				return true
+			}
+		}
+	case *types.Alias:
+		return hasTypeParams(types.Unalias(t))
+	case *types.Pointer:
+		return hasTypeParams(t.Elem())
+	case *types.Slice:
+		return hasTypeParams(t.Elem())
+	case *types.Array:
+		return hasTypeParams(t.Elem())
+	case *types.Chan:
+		return hasTypeParams(t.Elem())
+	case *types.Map:
+		return hasTypeParams(t.Key()) || hasTypeParams(t.Elem())
+	case *types.Struct:
+		for i := 0; i < t.NumFields(); i++ {
+			if hasTypeParams(t.Field(i).Type()) {
+				return true
+			}
+		}
+	case *types.Signature:
+		return hasTypeParams(t.Params()) || hasTypeParams(t.Results())
+	case *types.Tuple:
+		for i := 0; i < t.Len(); i++ {
+			if hasTypeParams(t.At(i).Type()) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 // parseStruct generates code for asserting struct equality.
+// Fields with the goequal:"-" tag are skipped, fields with the goequal:"func=..." tag are compared by the named function
+// the slices of fields with the goequal:"unordered" or goequal:"key=..." tags are compared regardless of order
+// and the floating-point numbers of fields with the goequal:"abs=...", goequal:"rel=..." or goequal:"ulps=..." tags within a tolerance.
 func (g *Generator) parseStruct(structType *types.Struct) string {
 	var result bytes.Buffer
 	for i := 0; i < structType.NumFields(); i++ {
 		field := structType.Field(i)
+		tag := g.getTag(field)
+		if tag.skip || g.isChain(field) || g.isSkippedUnexported(field) {
+			continue
+		}
+		if tag.function == "" {
+			g.checkStdlib(field, field.Type())
+		}
+		if tag.function != "" && !g.clone {
+			result.WriteString(g.parseFieldFunc(field, tag))
+			continue
+		}
+		// equal values within a tolerance have different hashes, so Hash functions don't hash these fields
+		if tag.hasTolerance() && g.hash {
+			continue
+		}
+		// the order of the elements doesn't matter for copies
+		if !g.clone {
+			g.tag = tag
+		}
 		result.WriteString(g.parseType(field.Name(), field.Type(), false, false))
+		g.tag = fieldTag{}
 	}
 	return result.String()
 }
 
+// parseFieldFunc generates code comparing a struct field with the function named in its goequal tag.
+// The function decides what equal values are, so Hash functions don't hash the field.
+// Clone functions copy the field as usual.
+func (g *Generator) parseFieldFunc(field *types.Var, tag fieldTag) string {
+	current := g.usedTypes[len(g.usedTypes)-1]
+	if tag.funcPkgPath != current.pkgPath && !token.IsExported(tag.funcName) {
+		g.errorf(field, "function %s of field %s is not exported, it can not be called outside package %s", tag.function, field.Name(), tag.funcPkgPath)
+		return ""
+	}
+	if g.hash {
+		return ""
+	}
+	name1, name2 := getNames(field.Name(), false)
+	call := fmt.Sprintf("!%s(%s, %s)", g.getReferenceUpdateImports(tag.funcPkgPath, tag.funcName), name1, name2)
+	return g.getCheck(call, g.getFailure(field.Name(), false, "DifferentValues", name1, name2), "")
+}
+
 // parseSlice generates code for asserting slice equality
 // two slices are considered equal if they have the same length and the same element for every index.
 func (g *Generator) parseSlice(name string, sliceType *types.Slice, isType bool) string {
+	// only the slice of the field is unordered, not the slices of its elements
+	unordered, key := g.tag.unordered, g.tag.key
+	g.tag.unordered, g.tag.key = false, ""
+	if g.hash {
+		// slices matched by key are equal if they have the same elements in any order, as multisets
+		return g.parseSliceHash(name, sliceType, isType, unordered || key != "")
+	}
+	if g.clone {
+		return g.parseSliceClone(name, sliceType, isType)
+	}
 	name1, name2 := getNames(name, isType)
 	// check for custom type
+	lenCond := fmt.Sprintf("len(%s) != len(%s)", name1, name2)
+	lenFailure := g.getFailure(name, isType, "DifferentLengths", "len("+name1+")", "len("+name2+")")
 	if ok, customCall := g.getEqualFunctionName(sliceType.Elem()); ok && customCall == "" {
-		return fmt.Sprintf("if len(%s) != len(%s) {\nreturn false\n}\n", name1, name2)
+		return g.getCheck(lenCond, lenFailure, "")
+	}
+	if unordered {
+		return g.getCheck(lenCond, lenFailure, g.parseUnorderedSlice(name, sliceType, isType))
+	}
+	if key != "" {
+		return g.getCheck(lenCond, lenFailure, g.parseKeyedSlice(name, sliceType, isType, key))
 	}
 	t, ok := sliceType.Elem().(*types.Basic)
 	if ok && t.Kind() == types.Byte {
 		funcName := g.getReferenceUpdateImports("bytes", "Equal")
-		return fmt.Sprintf("if !%s(%s, %s) {\n return false\n}\n", funcName, name1, name2)
+		return g.getCheck(fmt.Sprintf("!%s(%s, %s)", funcName, name1, name2), g.getFailure(name, isType, "DifferentValues", name1, name2), "")
 	}
-	var result bytes.Buffer
-	result.WriteString(fmt.Sprintf("if len(%s) != len(%s) {\nreturn false\n}\n", name1, name2))
 	// we want to find the index of looping through a slice
 	// because we can have inner loops, we will name our indexes i1, i2, etc
 	index := findNextUsableIndex(name, "i")
 	indexName := fmt.Sprintf("i%d", index)
 	referenceName := fmt.Sprintf("%s[%s]", name, indexName)
-	result.WriteString(fmt.Sprintf("for %s := range %s {\n%s}\n",
-		indexName, name1, g.parseType(referenceName, sliceType.Elem(), isType, false)))
+	loop := fmt.Sprintf("for %s := range %s {\n%s}\n",
+		indexName, name1, g.parseType(referenceName, sliceType.Elem(), isType, false))
+	return g.getCheck(lenCond, lenFailure, loop)
+}
+
+// parseUnorderedSlice generates code comparing two slices of the same length as multisets:
+// every element of a slice has its own equal element in the other slice.
+// Elements compared with == are counted in a map, other elements are matched using the code comparing the element type.
+// The code is put in a block, as it declares variables.
+func (g *Generator) parseUnorderedSlice(name string, sliceType *types.Slice, isType bool) string {
+	name1, name2 := getNames(name, isType)
+	index := findNextUsableIndex(name, "i")
+	indexName, otherIndexName := fmt.Sprintf("i%d", index), fmt.Sprintf("j%d", index)
+	elemName, otherElemName := fmt.Sprintf("%s[%s]", name, indexName), fmt.Sprintf("%s[%s]", name, otherIndexName)
+	elem1, elem2 := getNames(elemName, isType)
+	_, otherElem2 := getNames(otherElemName, isType)
+	var result bytes.Buffer
+	result.WriteString("{\n")
+	if g.isComparedByValue(sliceType.Elem()) {
+		counts := fmt.Sprintf("counts%d", index)
+		result.WriteString(fmt.Sprintf("%s := make(map[%s]int, len(%s))\nfor %s := range %s {\n%s[%s]++\n}\n",
+			counts, types.TypeString(sliceType.Elem(), g.qualifier), name1, indexName, name1, counts, elem1))
+		missing := g.getFailure(elemName, isType, "MissingElement", "", elem2)
+		result.WriteString(fmt.Sprintf("for %s := range %s {\n%s}\n", indexName, name2,
+			g.getCheck(fmt.Sprintf("%s[%s] == 0", counts, elem2), missing, fmt.Sprintf("%s[%s]--\n", counts, elem2))))
+		if g.diff {
+			// elements of the first slice left after all elements of the second slice were counted
+			result.WriteString(fmt.Sprintf("for %s := range %s {\nif %s[%s] > 0 {\n%s%s[%s]--\n}\n}\n",
+				indexName, name1, counts, elem1, g.getFailure(elemName, isType, "MissingElement", elem1, ""), counts, elem1))
+		}
+	} else {
+		eq, matched, found := fmt.Sprintf("eq%d", index), fmt.Sprintf("matched%d", index), fmt.Sprintf("found%d", index)
+		elemType := sliceType.Elem()
+		if containsLock(elemType) {
+			// elements with locks must not be copied, so they are compared and reported by pointer
+			elemType = types.NewPointer(elemType)
+			elem1, otherElem2 = "&"+elem1, "&"+otherElem2
+		}
+		result.WriteString(fmt.Sprintf("%s := %s\n%s := make([]bool, len(%s))\n", eq, g.getComparator(elemType), matched, name2))
+		missing := g.getFailure(elemName, isType, "MissingElement", elem1, "")
+		result.WriteString(fmt.Sprintf("for %s := range %s {\n%s := false\nfor %s := range %s {\nif !%s[%s] && %s(%s, %s) {\n%s[%s] = true\n%s = true\nbreak\n}\n}\n%s}\n",
+			indexName, name1, found, otherIndexName, name2, matched, otherIndexName, eq, elem1, otherElem2,
+			matched, otherIndexName, found, g.getCheck("!"+found, missing, "")))
+		if g.diff {
+			// elements of the second slice not matched by any element of the first slice
+			result.WriteString(fmt.Sprintf("for %s := range %s {\nif !%s[%s] {\n%s}\n}\n", otherIndexName, name2, matched, otherIndexName,
+				g.getFailure(otherElemName, isType, "MissingElement", "", otherElem2)))
+		}
+	}
+	result.WriteString("}\n")
+	return result.String()
+}
+
+// parseKeyedSlice generates code comparing two slices of the same length by pairing their elements with the same key:
+// the elements of every slice are grouped in a map by their key field, and the two maps are compared.
+// Elements with the same key are compared in the order they have in their slices, so a key found twice in a slice
+// is found twice in its copy too, and a different number of elements with a key makes the slices different.
+// Nil elements have no key and are left out, except in semantic mode.
+// The code is put in a block, as it declares variables.
+func (g *Generator) parseKeyedSlice(name string, sliceType *types.Slice, isType bool, key string) string {
+	current := g.usedTypes[len(g.usedTypes)-1]
+	field, err := keyField(sliceType, key, current.pkgPath)
+	if err != nil && current.pkgPath == g.outPkgPath {
+		// the key field is not exported, so it can not be read in the output package
+		g.errs.add(&Error{Msg: fmt.Sprintf("key %s of %s: %s", key, name, err)})
+		return ""
+	}
+	if err != nil {
+		// the key field was checked with the tag
+		panic(internalError{fmt.Sprintf("key %s of %s: %s", key, name, err)})
+	}
+	name1, name2 := getNames(name, isType)
+	// the elements are named as map values, so the reported paths have their keys
+	index := findNextUsableIndex(name, "key")
+	keyName := fmt.Sprintf("key%d", index)
+	elemName := fmt.Sprintf("%s[%s]", name, keyName)
+	value1, value2 := getNames(elemName, isType)
+	byKey1, byKey2 := fmt.Sprintf("byKey%d1", index), fmt.Sprintf("byKey%d2", index)
+	group1, group2, position := fmt.Sprintf("group%d1", index), fmt.Sprintf("group%d2", index), fmt.Sprintf("j%d", index)
+	// elements with locks must not be copied, so they are grouped by pointer
+	elemType, locked := sliceType.Elem(), containsLock(sliceType.Elem())
+	if locked {
+		elemType = types.NewPointer(elemType)
+	}
+	mapType := fmt.Sprintf("map[%s][]%s", types.TypeString(field.Type(), g.qualifier), types.TypeString(elemType, g.qualifier))
+	_, isPointer := sliceType.Elem().Underlying().(*types.Pointer)
+	var result bytes.Buffer
+	result.WriteString("{\n")
+	for _, s := range []struct{ byKey, value, slice string }{{byKey1, value1, name1}, {byKey2, value2, name2}} {
+		result.WriteString(fmt.Sprintf("%s := make(%s, len(%s))\n", s.byKey, mapType, s.slice))
+		if locked {
+			indexName := fmt.Sprintf("i%d", findNextUsableIndex(name, "i"))
+			result.WriteString(fmt.Sprintf("for %s := range %s {\n%s := &%s[%s]\n", indexName, s.slice, s.value, s.slice, indexName))
+		} else {
+			result.WriteString(fmt.Sprintf("for _, %s := range %s {\n", s.value, s.slice))
+		}
+		if isPointer && g.isSemantic() {
+			// a nil element is a zero value, with a zero key
+			result.WriteString(getZeroCheck(s.value, types.TypeString(sliceType.Elem().Underlying().(*types.Pointer).Elem(), g.qualifier)))
+		} else if isPointer {
+			result.WriteString(fmt.Sprintf("if %s == nil {\ncontinue\n}\n", s.value))
+		}
+		result.WriteString(fmt.Sprintf("%s := %s.%s\n%s[%s] = append(%s[%s], %s)\n}\n", keyName, s.value, key, s.byKey, keyName, s.byKey, keyName, s.value))
+	}
+	if isPointer && !g.diff && !g.isSemantic() {
+		// slices of the same length with a different number of nil elements
+		result.WriteString(fmt.Sprintf("if len(%s) != len(%s) {\nreturn false\n}\n", byKey1, byKey2))
+	}
+	// every element of a group missing from the other slice is reported
+	missing := g.getFailure(elemName, isType, "MissingKey", value1, "")
+	if g.diff {
+		missing = fmt.Sprintf("for _, %s := range %s {\n%s}\n", value1, group1, missing)
+	}
+	different := g.getFailure(elemName, isType, "DuplicateKey", group1, group2)
+	result.WriteString(fmt.Sprintf("for %s, %s := range %s {\nif %s, ok := %s[%s]; !ok {\n%s} else if len(%s) != len(%s) {\n%s}",
+		keyName, group1, byKey1, group2, byKey2, keyName, missing, group1, group2, different))
+	if valueCode := g.parseType(elemName, elemType, isType, false); valueCode != "" {
+		result.WriteString(fmt.Sprintf(" else {\nfor %s, %s := range %s {\n%s := %s[%s]\n%s}\n}", position, value1, group1, value2, group2, position, valueCode))
+	}
+	result.WriteString("\n}\n")
+	if g.diff {
+		// keys of the second slice missing from the first one
+		result.WriteString(fmt.Sprintf("for %s, %s := range %s {\nif _, ok := %s[%s]; !ok {\nfor _, %s := range %s {\n%s}\n}\n}\n",
+			keyName, group2, byKey2, byKey1, keyName, value2, group2, g.getFailure(elemName, isType, "MissingKey", "", value2)))
+	}
+	result.WriteString("}\n")
 	return result.String()
 }
 
+// isComparedByValue returns true if the code comparing values of typ uses ==, so they can be counted in a map.
+func (g *Generator) isComparedByValue(typ types.Type) bool {
+	switch t := types.Unalias(typ).(type) {
+	case *types.Basic:
+		return !g.isSpecialFloat(t)
+	case *types.TypeParam:
+		return isComparable(t)
+	case *types.Array:
+		return g.isComparedByValue(t.Elem())
+	case *types.Named:
+		// ignored named types are equal to any other value, types with Equal methods are equal as the methods say
+		if ok, _ := g.getEqualFunctionName(t); ok || g.getEqualMethod(t) != nil {
+			return false
+		}
+		_, isBasic := t.Underlying().(*types.Basic)
+		return isBasic
+	}
+	return false
+}
+
+// parseSliceHash generates code hashing a slice: its length and every element.
+// The elements of a slice compared as a multiset are hashed apart and their hashes are summed, as for maps.
+func (g *Generator) parseSliceHash(name string, sliceType *types.Slice, isType bool, unordered bool) string {
+	name1, hasher := getName(name, isType), getHasher(name)
+	// nil and empty slices are equal, so we hash the length and not the nil check
+	length := g.getWrite(hasher, "len("+name1+")")
+	if ok, customCall := g.getEqualFunctionName(sliceType.Elem()); ok && customCall == "" {
+		return length
+	}
+	if unordered {
+		// elements are named as map values, so they get their own hash
+		index := findNextUsableIndex(name, "key")
+		elemName := fmt.Sprintf("%s[key%d]", name, index)
+		if containsLock(sliceType.Elem()) {
+			// elements with locks must not be copied, so they are hashed by pointer
+			indexName := fmt.Sprintf("i%d", findNextUsableIndex(name, "i"))
+			loop := fmt.Sprintf("for %s := range %s", indexName, name1)
+			elem := fmt.Sprintf("%s := &%s[%s]\n", getName(elemName, isType), name1, indexName)
+			return length + g.getHashSum(hasher, getHasher(elemName), fmt.Sprintf("sum%d", index), loop,
+				elem+g.parseType(elemName, types.NewPointer(sliceType.Elem()), isType, false))
+		}
+		loop := fmt.Sprintf("for _, %s := range %s", getName(elemName, isType), name1)
+		return length + g.getHashSum(hasher, getHasher(elemName), fmt.Sprintf("sum%d", index), loop,
+			g.parseType(elemName, sliceType.Elem(), isType, false))
+	}
+	t, ok := sliceType.Elem().(*types.Basic)
+	if ok && t.Kind() == types.Byte {
+		return fmt.Sprintf("%s%s.Write(%s)\n", length, hasher, name1)
+	}
+	index := findNextUsableIndex(name, "i")
+	indexName := fmt.Sprintf("i%d", index)
+	referenceName := fmt.Sprintf("%s[%s]", name, indexName)
+	return fmt.Sprintf("%sfor %s := range %s {\n%s}\n",
+		length, indexName, name1, g.parseType(referenceName, sliceType.Elem(), isType, false))
+}
+
+// parseSliceClone generates code copying a slice: a new slice with a copy of every element.
+func (g *Generator) parseSliceClone(name string, sliceType *types.Slice, isType bool) string {
+	src, dst := getCloneNames(name, isType)
+	var elems string
+	if ok, customCall := g.getEqualFunctionName(sliceType.Elem()); ok && customCall == "" {
+		// only the length is compared
+	} else if _, ok := sliceType.Elem().(*types.Basic); ok {
+		elems = fmt.Sprintf("copy(%s, %s)\n", dst, src)
+	} else {
+		index := findNextUsableIndex(name, "i")
+		indexName := fmt.Sprintf("i%d", index)
+		referenceName := fmt.Sprintf("%s[%s]", name, indexName)
+		elems = fmt.Sprintf("for %s := range %s {\n%s}\n", indexName, src, g.parseType(referenceName, sliceType.Elem(), isType, false))
+	}
+	return fmt.Sprintf("if %s != nil {\n%s = make(%s, len(%s))\n%s}\n", src, dst, types.TypeString(sliceType, g.qualifier), src, elems)
+}
+
 // parseArray does what parseSlice does, except first it tries to do basic comparison.
 func (g *Generator) parseArray(name string, arrayType *types.Array, isType bool, isPointerReference bool) string {
 	// check for custom type
@@ -488,9 +2029,31 @@ func (g *Generator) parseArray(name string, arrayType *types.Array, isType bool,
 		// this should be true for all types that are not pointers and are comparable
 		name = "(*" + name + ")"
 	}
+	if g.clone {
+		src, dst := getCloneNames(name, isType)
+		if _, ok := arrayType.Elem().(*types.Basic); ok {
+			return fmt.Sprintf("%s = %s\n", dst, src)
+		}
+		index := findNextUsableIndex(name, "i")
+		indexName := fmt.Sprintf("i%d", index)
+		return fmt.Sprintf("for %s := range %s {\n%s}\n",
+			indexName, src, g.parseType(fmt.Sprintf("%s[%s]", name, indexName), arrayType.Elem(), isType, false))
+	}
+	// arrays of floating-point numbers not compared with == are compared element by element
+	_, isBasic := arrayType.Elem().(*types.Basic)
+	isBasic = isBasic && !g.isSpecialFloat(arrayType.Elem())
+	if g.hash {
+		if isBasic {
+			return g.getWrite(getHasher(name), getName(name, isType))
+		}
+		index := findNextUsableIndex(name, "i")
+		indexName := fmt.Sprintf("i%d", index)
+		return fmt.Sprintf("for %s := range %s {\n%s}\n",
+			indexName, getName(name, isType), g.parseType(fmt.Sprintf("%s[%s]", name, indexName), arrayType.Elem(), isType, false))
+	}
 	name1, name2 := getNames(name, isType)
-	if _, ok := arrayType.Elem().(*types.Basic); ok {
-		return fmt.Sprintf("if %s != %s {\nreturn false\n}\n", name1, name2)
+	if isBasic {
+		return g.getCheck(fmt.Sprintf("%s != %s", name1, name2), g.getFailure(name, isType, "DifferentValues", name1, name2), "")
 	}
 	var result bytes.Buffer
 	// we want to find the index of looping through a slice
@@ -505,10 +2068,19 @@ func (g *Generator) parseArray(name string, arrayType *types.Array, isType bool,
 
 // parseMap generates code for asserting map equality.
 // Two maps are considered equal if they have the same length and the same element for every key.
+// Diff functions report every missing key instead of different lengths.
 func (g *Generator) parseMap(name string, mapType *types.Map, isType bool) string {
+	if g.hash {
+		return g.parseMapHash(name, mapType, isType)
+	}
+	if g.clone {
+		return g.parseMapClone(name, mapType, isType)
+	}
 	name1, name2 := getNames(name, isType)
 	var result bytes.Buffer
-	result.WriteString(fmt.Sprintf("if len(%s) != len(%s) {\nreturn false\n}\n", name1, name2))
+	if !g.diff {
+		result.WriteString(fmt.Sprintf("if len(%s) != len(%s) {\nreturn false\n}\n", name1, name2))
+	}
 	// we want to find the key of looping through a map
 	// because we can have inner loops, we will name our indexes key1, key2, etc
 	index := findNextUsableIndex(name, "key")
@@ -516,16 +2088,94 @@ func (g *Generator) parseMap(name string, mapType *types.Map, isType bool) strin
 	newName := fmt.Sprintf("%s[%s]", name, keyName)
 	value1, value2 := getNames(newName, isType)
 	// check for custom type
-	if ok, customCall := g.getEqualFunctionName(mapType.Elem()); ok && customCall == "" {
+	valueCode := ""
+	if ok, customCall := g.getEqualFunctionName(mapType.Elem()); !ok || customCall != "" {
+		valueCode = g.parseType(newName, mapType.Elem(), isType, false)
+	}
+	if valueCode == "" && !g.diff {
+		// values have nothing to compare, e.g.: struct{}
 		result.WriteString(fmt.Sprintf("for %s := range %s {\nif _, ok := %s[%s]; !ok {\nreturn false\n}\n}\n", keyName, name1, name2, keyName))
 		return result.String()
 	}
-	result.WriteString(fmt.Sprintf(
-		"for %s, %s := range %s {\nif %s, ok := %s[%s]; !ok {\nreturn false\n} else {\n%s}\n}\n",
-		keyName, value1, name1, value2, name2, keyName, g.parseType(newName, mapType.Elem(), isType, false)))
+	var loops bytes.Buffer
+	if valueCode == "" {
+		loops.WriteString(fmt.Sprintf("for %s, %s := range %s {\nif _, ok := %s[%s]; !ok {\n%s}\n}\n",
+			keyName, value1, name1, name2, keyName, g.getFailure(newName, isType, "MissingKey", value1, "")))
+	} else {
+		loops.WriteString(fmt.Sprintf(
+			"for %s, %s := range %s {\nif %s, ok := %s[%s]; !ok {\n%s} else {\n%s}\n}\n",
+			keyName, value1, name1, value2, name2, keyName, g.getFailure(newName, isType, "MissingKey", value1, ""), valueCode))
+	}
+	if g.diff {
+		// keys of the second map missing from the first one
+		loops.WriteString(fmt.Sprintf("for %s, %s := range %s {\nif _, ok := %s[%s]; !ok {\n%s}\n}\n",
+			keyName, value2, name2, name1, keyName, g.getFailure(newName, isType, "MissingKey", "", value2)))
+	}
+	if g.visitsMap(mapType) {
+		// map values are copies, so a pair of maps compared again is equal, as the values of its maps are never visited again
+		result.WriteString(fmt.Sprintf("if !visited.Visit(%s, %s) {\n%s}\n", name1, name2, loops.String()))
+	} else {
+		result.WriteString(loops.String())
+	}
 	return result.String()
 }
 
+// parseMapHash generates code hashing a map.
+// Every entry is hashed apart and the entry hashes are summed, so the map hash doesn't depend on the iteration order.
+// The code is put in a block, as it declares variables.
+func (g *Generator) parseMapHash(name string, mapType *types.Map, isType bool) string {
+	name1, hasher := getName(name, isType), getHasher(name)
+	index := findNextUsableIndex(name, "key")
+	keyName := fmt.Sprintf("key%d", index)
+	newName := fmt.Sprintf("%s[%s]", name, keyName)
+	entryHasher, sumName := getHasher(newName), fmt.Sprintf("sum%d", index)
+	valueCode := ""
+	if ok, customCall := g.getEqualFunctionName(mapType.Elem()); !ok || customCall != "" {
+		valueCode = g.parseType(newName, mapType.Elem(), isType, false)
+	}
+	loopVars := keyName
+	if valueCode != "" {
+		loopVars += ", " + getName(newName, isType)
+	}
+	loop := fmt.Sprintf("for %s := range %s", loopVars, name1)
+	return g.getHashSum(hasher, entryHasher, sumName, loop, g.getWrite(entryHasher, keyName)+valueCode)
+}
+
+// getHashSum returns code hashing every entry apart and writing the sum of the entry hashes to hasher,
+// so the hash doesn't depend on the order of the entries.
+// loop is the for clause iterating the entries and entryCode hashes an entry with entryHasher.
+// The code is put in a block, as it declares variables.
+func (g *Generator) getHashSum(hasher, entryHasher, sumName, loop, entryCode string) string {
+	var result bytes.Buffer
+	result.WriteString(fmt.Sprintf("{\nvar %s uint64\n%s := new(%s)\n%s.SetSeed(%s.Seed())\n",
+		sumName, entryHasher, g.getReferenceUpdateImports("hash/maphash", "Hash"), entryHasher, hasher))
+	result.WriteString(fmt.Sprintf("%s {\n%s.Reset()\n%s%s += %s.Sum64()\n}\n", loop, entryHasher, entryCode, sumName, entryHasher))
+	result.WriteString(g.getWrite(hasher, sumName))
+	result.WriteString("}\n")
+	return result.String()
+}
+
+// parseMapClone generates code copying a map: a new map with a copy of every value.
+// Values are copied in a variable, as they are not addressable in the map.
+func (g *Generator) parseMapClone(name string, mapType *types.Map, isType bool) string {
+	src, dst := getCloneNames(name, isType)
+	index := findNextUsableIndex(name, "key")
+	keyName := fmt.Sprintf("key%d", index)
+	newName := fmt.Sprintf("%s[%s]", name, keyName)
+	value1, value2 := getCloneNames(newName, isType)
+	valueCode := ""
+	if ok, customCall := g.getEqualFunctionName(mapType.Elem()); !ok || customCall != "" {
+		valueCode = g.parseType(newName, mapType.Elem(), isType, false)
+	}
+	loopVars := keyName
+	if valueCode != "" {
+		loopVars += ", " + value1
+	}
+	return fmt.Sprintf("if %s != nil {\n%s = make(%s, len(%s))\nfor %s := range %s {\nvar %s %s\n%s%s[%s] = %s\n}\n}\n",
+		src, dst, types.TypeString(mapType, g.qualifier), src, loopVars, src,
+		value2, types.TypeString(mapType.Elem(), g.qualifier), valueCode, dst, keyName, value2)
+}
+
 // parsePointer generates code for asserting pointer equality
 // Two pointers are equal if they are equal as pointers or if the values they point to are equal.
 func (g *Generator) parsePointer(name string, pointerType *types.Pointer, isType bool) string {
@@ -534,18 +2184,93 @@ func (g *Generator) parsePointer(name string, pointerType *types.Pointer, isType
 	// e.g.: (*f)[0]
 	// can also be a type defined as a pointer to another type
 	name1, name2 := getNames(name, isType)
+	if g.hash {
+		return g.parsePointerHash(name, pointerType, isType)
+	}
+	if g.clone {
+		return g.parsePointerClone(name, pointerType, isType)
+	}
 	// check for custom type
+	nilCond := fmt.Sprintf("%s == nil || %s == nil", name1, name2)
 	if ok, customCall := g.getEqualFunctionName(pointerType.Elem()); ok && customCall == "" {
-		return fmt.Sprintf("if %s != %s {\nif %s == nil || %s == nil {\nreturn false\n}\n}\n", name1, name2, name1, name2)
+		if g.isSemantic() {
+			// ignored values are equal to any value, including zero values
+			return ""
+		}
+		nilFailure := g.getFailure(name, isType, "NilValue", name1, name2)
+		return fmt.Sprintf("if %s != %s {\n%s}\n", name1, name2, g.getCheck(nilCond, nilFailure, ""))
 	}
 	// generally we dereference the name, but not for named types, because they are handled separately in parseType
-	if _, ok := pointerType.Elem().(*types.Named); ok {
+	if g.hasFunc(pointerType.Elem()) {
 		resultParseType := g.parseType(name, pointerType.Elem(), isType, true)
 		// we don't check here for non nil pointers because it is done in parseTypeDef or parseType
 		return resultParseType
 	}
+	nilFailure := g.getFailure(name, isType, "NilValue", name1, name2)
 	resultParseType := g.parseType("(*"+name+")", pointerType.Elem(), isType, true)
-	return fmt.Sprintf("if %s != %s {\nif %s == nil || %s == nil {\nreturn false\n}\n%s}\n", name1, name2, name1, name2, resultParseType)
+	if g.isSemantic() {
+		resultParseType = g.replaceOrZero(resultParseType, name1, name2)
+		return fmt.Sprintf("if %s != %s {\n%s}\n", name1, name2, resultParseType)
+	}
+	return fmt.Sprintf("if %s != %s {\n%s}\n", name1, name2, g.getCheck(nilCond, nilFailure, resultParseType))
+}
+
+// hasFunc returns true if typ is a named type compared by its own generated function, which checks pointers for nil.
+func (g *Generator) hasFunc(typ types.Type) bool {
+	named, ok := types.Unalias(typ).(*types.Named)
+	if !ok || g.isInlined(named) || g.getEqualMethod(named) != nil {
+		return false
+	}
+	_, isStdlib := g.getStdlibComparison(named)
+	return !isStdlib
+}
+
+// replaceOrZero replaces in code the dereferenced pointers with dereferenced pointers to zero values, if they are nil.
+// e.g.: (*t1.a).b -> (*equal.OrZero(t1.a)).b
+// The names of the values pointed to are built from the pointer names, so the code has them only where they are dereferenced.
+func (g *Generator) replaceOrZero(code string, names ...string) string {
+	var replacements []string
+	for _, name := range names {
+		replacements = append(replacements, "(*"+name+")", "(*"+g.getOrZero(name)+")")
+	}
+	return strings.NewReplacer(replacements...).Replace(code)
+}
+
+// parsePointerHash generates code hashing a pointer: whether it is nil and the value it points to.
+func (g *Generator) parsePointerHash(name string, pointerType *types.Pointer, isType bool) string {
+	name1, hasher := getName(name, isType), getHasher(name)
+	if ok, customCall := g.getEqualFunctionName(pointerType.Elem()); ok && customCall == "" {
+		if g.isSemantic() {
+			// nil is equal to any value, so it is not hashed
+			return ""
+		}
+		return getNilCheck(hasher, name1, "")
+	}
+	// Hash functions of named types check for nil themselves
+	if g.hasFunc(pointerType.Elem()) {
+		return g.parseType(name, pointerType.Elem(), isType, true)
+	}
+	valueCode := g.parseType("(*"+name+")", pointerType.Elem(), isType, true)
+	if g.isSemantic() {
+		// nil is hashed as a zero value
+		return g.replaceOrZero(valueCode, name1)
+	}
+	return getNilCheck(hasher, name1, valueCode)
+}
+
+// parsePointerClone generates code copying a pointer: a new pointer to a copy of the value.
+func (g *Generator) parsePointerClone(name string, pointerType *types.Pointer, isType bool) string {
+	src, dst := getCloneNames(name, isType)
+	valueCode := ""
+	if ok, customCall := g.getEqualFunctionName(pointerType.Elem()); !ok || customCall != "" {
+		// Clone functions of named types check for nil themselves
+		if g.hasFunc(pointerType.Elem()) {
+			return g.parseType(name, pointerType.Elem(), isType, true)
+		}
+		valueCode = g.parseType("(*"+name+")", pointerType.Elem(), isType, true)
+	}
+	// nil pointers are not equal to other pointers, even if the value is not compared
+	return fmt.Sprintf("if %s != nil {\n%s = new(%s)\n%s}\n", src, dst, types.TypeString(pointerType.Elem(), g.qualifier), valueCode)
 }
 
 // findNextUsableIndex finds last int that is preceded by key and is enclosed in square brackets.
@@ -588,6 +2313,43 @@ func findLastUnpairedParantheses(s string) int {
 	return -1
 }
 
+// getName returns the name of the variable used in hashing inside a Hash function.
+// It is the first name returned by getNames, except that Hash functions have a single value, named t.
+// e.g.: X -> t, a -> t.a, (*a[i1]) -> (*t.a[i1]), a[key1] -> value11
+func getName(name string, isType bool) string {
+	name1, _ := getNames(name, isType)
+	return renameParam(name1, "t1", "t")
+}
+
+// getCloneNames returns the names of the copied variable and of its copy inside a Clone function.
+// They are the names returned by getNames, except that the values of Clone functions are named src and dst.
+// e.g.: X -> src, dst, a -> src.a, dst.a, a[key1] -> value11, value12
+func getCloneNames(name string, isType bool) (string, string) {
+	name1, name2 := getNames(name, isType)
+	return renameParam(name1, "t1", "src"), renameParam(name2, "t2", "dst")
+}
+
+// renameParam renames the function parameter param, if name starts with it.
+func renameParam(name, param, newParam string) string {
+	if i := strings.Index(name, param); i > -1 && strings.Trim(name[:i], "(*") == "" {
+		return name[:i] + newParam + name[i+len(param):]
+	}
+	return name
+}
+
+// getHasher returns the hash used for the value named name inside a Hash function.
+// Map entries are hashed apart, with h1, h2, etc. for the maps looped with key1, key2, etc.
+// e.g.: a -> h, a[key1][i1] -> h1, a[key1][key2] -> h2
+func getHasher(name string) string {
+	for i := strings.LastIndex(name, "[key"); i > -1; i = strings.LastIndex(name[:i], "[key") {
+		end := strings.Index(name[i:], "]")
+		if _, err := strconv.Atoi(name[i+4 : i+end]); err == nil {
+			return "h" + name[i+4:i+end]
+		}
+	}
+	return "h"
+}
+
 // getNames returns the names of variables used in testing equality inside an equal function.
 // name is the name of original variable or type.
 func getNames(name string, isType bool) (string, string) {
@@ -634,7 +2396,7 @@ func getNames(name string, isType bool) (string, string) {
 		}
 	}
 	if startVar == -1 {
-		log.Fatalf("Wrong variable name:%s\n", name)
+		panic(internalError{fmt.Sprintf("Wrong variable name:%s", name)})
 	}
 	var name1, name2 string
 	if isType {
@@ -1,12 +1,17 @@
 package equal
 
 import (
+	"container/list"
 	"fmt"
 	"go/ast"
 	"go/importer"
 	"go/parser"
 	"go/token"
 	"go/types"
+	"math"
+	"reflect"
+	"sort"
+	"sync"
 	"testing"
 )
 
@@ -59,12 +64,14 @@ var golden = []Golden{
 	{"interfaceVar5", interfaceVar5In, interfaceVar5Out},
 	{"interfaceType", interfaceTypeIn, interfaceTypeOut},
 	{"interfaceType2", interfaceType2In, interfaceType2Out},
+	{"interfaceStdlib", interfaceStdlibIn, interfaceStdlibOut},
 
 	{"stdVar", stdVarIn, stdVarOut},
 	{"stdVar2", stdVar2In, stdVar2Out},
 	{"stdVar3", stdVar3In, stdVar3Out},
 	{"stdVar4", stdVar4In, stdVar4Out},
 	{"stdVar5", stdVar5In, stdVar5Out},
+	{"stdComparable", stdComparableIn, stdComparableOut},
 
 	{"chanVar", chanVarIn, chanVarOut},
 	{"chanVar2", chanVar2In, chanVar2Out},
@@ -75,6 +82,9 @@ var golden = []Golden{
 	{"funcType", funcTypeIn, funcTypeOut},
 
 	{"treeVar", treeVarIn, treeVarOut},
+
+	{"genericType", genericTypeIn, genericTypeOut},
+	{"genericComparableType", genericComparableTypeIn, genericComparableTypeOut},
 }
 
 // pointer variable
@@ -932,7 +942,7 @@ type Test struct {
 `
 
 var interfaceVarOut = `
-import "reflect"
+import "github.com/gadumitrachioaiei/goequal/equal"
 
 func EqualTest(t1, t2 *Test) bool {
 	if t1 == t2 {
@@ -941,7 +951,7 @@ func EqualTest(t1, t2 *Test) bool {
 	if t1 == nil || t2 == nil {
 		return false
 	}
-	if !reflect.DeepEqual(t1.a, t2.a) {
+	if !equal.Interface(t1.a, t2.a) {
 		return false
 	}
 	return true
@@ -983,7 +993,7 @@ type Test struct {
 `
 
 var interfaceVar3Out = `
-import "reflect"
+import "github.com/gadumitrachioaiei/goequal/equal"
 
 func EqualTest(t1, t2 *Test) bool {
 	if t1 == t2 {
@@ -996,7 +1006,7 @@ func EqualTest(t1, t2 *Test) bool {
 		return false
 	}
 	for i1 := range t1.a {
-		if !reflect.DeepEqual(t1.a[i1], t2.a[i1]) {
+		if !equal.Interface(t1.a[i1], t2.a[i1]) {
 			return false
 		}
 	}
@@ -1013,7 +1023,7 @@ type Test struct {
 `
 
 var interfaceVar4Out = `
-import "reflect"
+import "github.com/gadumitrachioaiei/goequal/equal"
 
 func EqualTest(t1, t2 *Test) bool {
 	if t1 == t2 {
@@ -1023,7 +1033,7 @@ func EqualTest(t1, t2 *Test) bool {
 		return false
 	}
 	for i1 := range t1.a {
-		if !reflect.DeepEqual(t1.a[i1], t2.a[i1]) {
+		if !equal.Interface(t1.a[i1], t2.a[i1]) {
 			return false
 		}
 	}
@@ -1040,7 +1050,7 @@ type Test struct {
 `
 
 var interfaceVar5Out = `
-import "reflect"
+import "github.com/gadumitrachioaiei/goequal/equal"
 
 func EqualTest(t1, t2 *Test) bool {
 	if t1 == t2 {
@@ -1056,7 +1066,7 @@ func EqualTest(t1, t2 *Test) bool {
 		if value12, ok := t2.a[key1]; !ok {
 			return false
 		} else {
-			if !reflect.DeepEqual(value11, value12) {
+			if !equal.Interface(value11, value12) {
 				return false
 			}
 		}
@@ -1072,10 +1082,40 @@ type Test interface{}
 `
 
 var interfaceTypeOut = `
-import "reflect"
+import "github.com/gadumitrachioaiei/goequal/equal"
 
 func EqualTest(t1, t2 Test) bool {
-	if !reflect.DeepEqual(t1, t2) {
+	if !equal.Interface(t1, t2) {
+		return false
+	}
+	return true
+}
+`
+
+// interfaces of standard library and error
+var interfaceStdlibIn = `
+package test
+import "io"
+type Test struct {
+	r   io.Reader
+	err error
+}
+`
+
+var interfaceStdlibOut = `
+import "github.com/gadumitrachioaiei/goequal/equal"
+
+func EqualTest(t1, t2 *Test) bool {
+	if t1 == t2 {
+		return true
+	}
+	if t1 == nil || t2 == nil {
+		return false
+	}
+	if !equal.Interface(t1.r, t2.r) {
+		return false
+	}
+	if !equal.Interface(t1.err, t2.err) {
 		return false
 	}
 	return true
@@ -1089,14 +1129,14 @@ type Test []interface{}
 `
 
 var interfaceType2Out = `
-import "reflect"
+import "github.com/gadumitrachioaiei/goequal/equal"
 
 func EqualTest(t1, t2 Test) bool {
 	if len(t1) != len(t2) {
 		return false
 	}
 	for i1 := range t1 {
-		if !reflect.DeepEqual(t1[i1], t2[i1]) {
+		if !equal.Interface(t1[i1], t2[i1]) {
 			return false
 		}
 	}
@@ -1124,6 +1164,38 @@ func EqualTest(t1, t2 *Test) bool {
 }
 `
 
+// comparable types of standard library
+var stdComparableIn = `
+package test
+import "net/netip"
+type Test struct {
+	addr     netip.Addr
+	prefixes []netip.Prefix
+}
+`
+var stdComparableOut = `
+func EqualTest(t1, t2 *Test) bool {
+	if t1 == t2 {
+		return true
+	}
+	if t1 == nil || t2 == nil {
+		return false
+	}
+	if t1.addr != t2.addr {
+		return false
+	}
+	if len(t1.prefixes) != len(t2.prefixes) {
+		return false
+	}
+	for i1 := range t1.prefixes {
+		if t1.prefixes[i1] != t2.prefixes[i1] {
+			return false
+		}
+	}
+	return true
+}
+`
+
 // std var 2
 var stdVar2In = `
 package test
@@ -1133,6 +1205,8 @@ type Test struct {
 }
 `
 var stdVar2Out = `
+import "github.com/gadumitrachioaiei/goequal/equal"
+
 func EqualTest(t1, t2 *Test) bool {
 	if t1 == t2 {
 		return true
@@ -1143,6 +1217,11 @@ func EqualTest(t1, t2 *Test) bool {
 	if len(t1.b) != len(t2.b) {
 		return false
 	}
+	for i1 := range t1.b {
+		if !equal.Interface(t1.b[i1].Load(), t2.b[i1].Load()) {
+			return false
+		}
+	}
 	return true
 }
 `
@@ -1156,6 +1235,8 @@ type Test struct {
 }
 `
 var stdVar3Out = `
+import "github.com/gadumitrachioaiei/goequal/equal"
+
 func EqualTest(t1, t2 *Test) bool {
 	if t1 == t2 {
 		return true
@@ -1163,6 +1244,11 @@ func EqualTest(t1, t2 *Test) bool {
 	if t1 == nil || t2 == nil {
 		return false
 	}
+	for i1 := range t1.b {
+		if !equal.Interface(t1.b[i1].Load(), t2.b[i1].Load()) {
+			return false
+		}
+	}
 	return true
 }
 `
@@ -1176,6 +1262,8 @@ type Test struct {
 }
 `
 var stdVar4Out = `
+import "github.com/gadumitrachioaiei/goequal/equal"
+
 func EqualTest(t1, t2 *Test) bool {
 	if t1 == t2 {
 		return true
@@ -1186,9 +1274,13 @@ func EqualTest(t1, t2 *Test) bool {
 	if len(t1.b) != len(t2.b) {
 		return false
 	}
-	for key1 := range t1.b {
-		if _, ok := t2.b[key1]; !ok {
+	for key1, value11 := range t1.b {
+		if value12, ok := t2.b[key1]; !ok {
 			return false
+		} else {
+			if !equal.Interface(value11.Load(), value12.Load()) {
+				return false
+			}
 		}
 	}
 	return true
@@ -1204,6 +1296,8 @@ type Test struct {
 }
 `
 var stdVar5Out = `
+import "github.com/gadumitrachioaiei/goequal/equal"
+
 func EqualTest(t1, t2 *Test) bool {
 	if t1 == t2 {
 		return true
@@ -1215,6 +1309,9 @@ func EqualTest(t1, t2 *Test) bool {
 		if t1.b == nil || t2.b == nil {
 			return false
 		}
+		if !equal.Interface((*t1.b).Load(), (*t2.b).Load()) {
+			return false
+		}
 	}
 	return true
 }
@@ -1343,18 +1440,87 @@ type Test struct {
 `
 var treeVarOut = `
 func EqualTest(t1, t2 *Test) bool {
+	for {
+		if t1 == t2 {
+			return true
+		}
+		if t1 == nil || t2 == nil {
+			return false
+		}
+		if t1.a != t2.a {
+			return false
+		}
+		t1, t2 = t1.t1, t2.t1
+	}
+}
+`
+
+// generic type
+var genericTypeIn = `
+package test
+type Test[T any] struct {
+	Items []T
+	Next  *string
+	first *T
+}
+`
+
+var genericTypeOut = `
+func EqualTest[T any](t1, t2 *Test[T], eqT func(a, b T) bool) bool {
 	if t1 == t2 {
 		return true
 	}
 	if t1 == nil || t2 == nil {
 		return false
 	}
-	if t1.a != t2.a {
+	if len(t1.Items) != len(t2.Items) {
 		return false
 	}
-	if !EqualTest(t1.t1, t2.t1) {
+	for i1 := range t1.Items {
+		if !eqT(t1.Items[i1], t2.Items[i1]) {
+			return false
+		}
+	}
+	if t1.Next != t2.Next {
+		if t1.Next == nil || t2.Next == nil {
+			return false
+		}
+		if (*t1.Next) != (*t2.Next) {
+			return false
+		}
+	}
+	if t1.first != t2.first {
+		if t1.first == nil || t2.first == nil {
+			return false
+		}
+		if !eqT((*t1.first), (*t2.first)) {
+			return false
+		}
+	}
+	return true
+}
+`
+
+// generic type with comparable type parameter
+var genericComparableTypeIn = `
+package test
+type Test[K comparable, V any] map[K]V
+`
+
+var genericComparableTypeOut = `
+func EqualTest[K comparable, V any](t1, t2 Test[K, V], eqV func(a, b V) bool) bool {
+	if len(t1) != len(t2) {
 		return false
 	}
+	for key1, value11 := range t1 {
+		if value12, ok := t2[key1]; !ok {
+			return false
+		} else {
+			if !eqV(value11, value12) {
+				return false
+			}
+		}
+	}
 	return true
 }
 `
@@ -1364,8 +1530,11 @@ func TestGolden(t *testing.T) {
 	typ, pkgPath := "Test", "test"
 	for _, test := range golden {
 		input := map[string]interface{}{pkgPath: test.input}
-		g := NewGenerator(pkgPath, typ, false, input)
-		g.parse()
+		g := NewGenerator(pkgPath, typ, false, input, Options{})
+		if err := g.parse(); err != nil {
+			t.Errorf("test: %s, unexpected error: %s", test.name, err)
+			continue
+		}
 		expectedType := Type{typ, pkgPath}
 		code := g.equals[expectedType]
 		failed := false
@@ -1373,7 +1542,10 @@ func TestGolden(t *testing.T) {
 			t.Errorf("expected code for type: %v", expectedType)
 			failed = true
 		} else {
-			_, generatedCode := code.serialize()
+			_, generatedCode, err := code.serialize()
+			if err != nil {
+				t.Errorf("test: %s, type: %v, %s", test.name, expectedType, err)
+			}
 			expectedCode := fmt.Sprintf(generatedHeader, typ) + test.output
 			if expectedCode != string(generatedCode) {
 				t.Errorf("test: %s, type: %v, expected:\n%s, found:\n%s", test.name, expectedType, expectedCode, string(generatedCode))
@@ -1388,7 +1560,7 @@ func TestGolden(t *testing.T) {
 			for _, code := range g.equals {
 				counter++
 				files = append(files, fmt.Sprintf("f%d.go", counter))
-				_, generatedCode := code.serialize()
+				_, generatedCode, _ := code.serialize()
 				inputs = append(inputs, string(generatedCode))
 			}
 			if err := compile(files, inputs); err != nil {
@@ -1398,7 +1570,441 @@ func TestGolden(t *testing.T) {
 	}
 }
 
+// TestErrors tests that problems are returned as errors with positions
+func TestErrors(t *testing.T) {
+	tests := []struct {
+		name, typ string
+		input     map[string]interface{}
+		errs      []string
+	}{
+		{"missingType", "Missing", map[string]interface{}{"test": "package test\ntype Test int\n"}, []string{
+			"Type:Missing was not found in package:test",
+		}},
+		{"badPattern", "Test,^Event(", map[string]interface{}{"test": "package test\ntype Test int\n"}, []string{
+			"type pattern ^Event( is not a valid regular expression: error parsing regexp: missing closing ): `^Event(`",
+		}},
+		{"noMatch", "^Event.*", map[string]interface{}{"test": "package test\ntype Test int\n"}, []string{
+			"no type matches ^Event.* in package test",
+		}},
+		{"typeErrors", "Test", map[string]interface{}{"test": "package test\ntype Test struct {\n\ta Unknown\n\tb Unknown2\n}\n"}, []string{
+			"test.go:3:4: undefined: Unknown",
+			"test.go:4:4: undefined: Unknown2",
+		}},
+		{"unexportedInstanceField", "Test", map[string]interface{}{
+			"test":  "package test\nimport \"test2\"\ntype Test struct {\n\ta test2.Entry[int]\n}\n",
+			"test2": "package test2\ntype Entry[T any] struct {\n\tValue T\n\thits int\n}\n",
+		}, []string{
+			"test.go:4:2: field hits of test2.Entry[int] is not exported, it can not be compared outside package test2",
+		}},
+		{"unknownTag", "Test", map[string]interface{}{"test": "package test\ntype Test struct {\n\ta int `goequal:\"skip\"`\n}\n"}, []string{
+			"test.go:3:8: unknown goequal tag: \"skip\"",
+		}},
+		{"funcTag", "Test", map[string]interface{}{"test": "package test\n" +
+			"type Test struct {\n\ta []int `goequal:\"func=same\"`\n\tb int `goequal:\"func=missing\"`\n\tc int `goequal:\"func=Test\"`\n}\n" +
+			"func same(a, b []string) bool { return true }\n"}, []string{
+			"test.go:3:10: function same has type func(a []string, b []string) bool, field a needs func(a, b []int) bool",
+			"test.go:4:8: function missing of field b was not found in package test",
+			"test.go:5:8: Test is not a function, field c needs func(a, b int) bool",
+		}},
+		{"unorderedTag", "Test", map[string]interface{}{"test": "package test\n" +
+			"type Test struct {\n\ta int `goequal:\"unordered\"`\n\tb map[string]*[]int `goequal:\"unordered\"`\n\tc []int `goequal:\"unordered,func=same\"`\n}\n"}, []string{
+			"test.go:3:8: field a of type int is not a slice or a map of slices, it can not be compared regardless of order",
+			"test.go:5:10: goequal tag options func and unordered can not be used together: \"unordered,func=same\"",
+		}},
+		{"keyTag", "Test", map[string]interface{}{"test": "package test\n" +
+			"type Item struct {\n\tID int\n\tTags []string\n}\n" +
+			"type Test struct {\n\ta []*Item `goequal:\"key=Name\"`\n\tb []Item `goequal:\"key=Tags\"`\n\tc []int `goequal:\"key=ID\"`\n\td []Item `goequal:\"key=ID,unordered\"`\n}\n"}, []string{
+			"test.go:7:12: field a can not be matched by key: element type *test.Item has no field Name",
+			"test.go:8:11: field b can not be matched by key: key field Tags of type []string is not comparable",
+			"test.go:9:10: field c can not be matched by key: element type int is not a struct",
+			"test.go:10:11: goequal tag options key and unordered can not be used together: \"key=ID,unordered\"",
+		}},
+		{"toleranceTag", "Test", map[string]interface{}{"test": "package test\n" +
+			"type Test struct {\n\ta float64 `goequal:\"abs=-1\"`\n\tb float64 `goequal:\"ulps=1.5\"`\n\tc []int `goequal:\"rel=1e-6\"`\n\td float64 `goequal:\"abs=1,func=same\"`\n" +
+			"\te []float64 `goequal:\"unordered,abs=1\"`\n}\n" +
+			"func same(a, b float64) bool { return true }\n"}, []string{
+			"test.go:3:12: abs tolerance in goequal tag is not a non-negative number: \"abs=-1\"",
+			"test.go:4:12: ulps tolerance in goequal tag is not a non-negative integer: \"ulps=1.5\"",
+			"test.go:5:10: field c of type []int has no floating-point numbers, it can not have a tolerance",
+			"test.go:6:12: goequal tag options func and abs can not be used together: \"abs=1,func=same\"",
+			"test.go:7:14: goequal tag options unordered and abs can not be used together: \"unordered,abs=1\"",
+		}},
+		{"semanticTag", "Test", map[string]interface{}{"test": "package test\n" +
+			"type Test struct {\n\ta []int `goequal:\"semantic\"`\n\tb *int `goequal:\"semantic,func=same\"`\n}\n" +
+			"func same(a, b *int) bool { return true }\n"}, []string{
+			"test.go:3:10: field a of type []int has no pointers, it can not be semantic",
+			"test.go:4:9: goequal tag options func and semantic can not be used together: \"semantic,func=same\"",
+		}},
+		{"unknownStdlib", "Test,List", map[string]interface{}{"test": "package test\nimport (\n\t\"bytes\"\n\t\"time\"\n)\n" +
+			"type Test struct {\n\ta bytes.Buffer\n\tb map[string][]*time.Location\n\tc bytes.Buffer `goequal:\"-\"`\n}\ntype List []bytes.Buffer\n"}, []string{
+			"test.go:7:2: type bytes.Buffer of standard library has no known comparison, compare field a with a goequal:\"func=...\" tag or skip it with goequal:\"-\"",
+			"test.go:8:2: type time.Location of standard library has no known comparison, compare field b with a goequal:\"func=...\" tag or skip it with goequal:\"-\"",
+			"test.go:11:6: type bytes.Buffer of standard library has no known comparison, ignore type List with the //goequal:ignore directive",
+		}},
+		{"unexportedFunc", "Test", map[string]interface{}{
+			"test":  "package test\nimport \"test2\"\ntype Test struct {\n\ta test2.Entry[int]\n}\n",
+			"test2": "package test2\ntype Entry[T any] struct {\n\tValue int `goequal:\"func=same\"`\n}\nfunc same(a, b int) bool { return true }\n",
+		}, []string{
+			"test.go:3:2: function same of field Value is not exported, it can not be called outside package test2",
+		}},
+	}
+	for _, test := range tests {
+		g := NewGenerator("test", test.typ, true, test.input, Options{})
+		err := g.Generate()
+		errs, ok := err.(Errors)
+		if !ok {
+			t.Errorf("test: %s, expected Errors, found: %v", test.name, err)
+			continue
+		}
+		if len(errs) != len(test.errs) {
+			t.Errorf("test: %s, expected %d errors, found: %v", test.name, len(test.errs), errs)
+			continue
+		}
+		for i, msg := range test.errs {
+			if errs[i].Error() != msg {
+				t.Errorf("test: %s, expected error: %s, found: %s", test.name, msg, errs[i])
+			}
+		}
+	}
+}
+
+// TestEqualMethodConflict tests that Equal methods are not generated for types which already have one
+// TestRootTypes tests that the functions of many types, named or matched, are generated in one run, each one once.
+func TestRootTypes(t *testing.T) {
+	input := map[string]interface{}{"test": "package test\n" +
+		"type Line struct {\n\tQty int\n}\ntype Order struct {\n\tLines []Line\n}\n" +
+		"type EventCreated struct {\n\tOrder Order\n}\ntype EventDeleted struct {\n\tID int\n}\n" +
+		"type Status int\ntype Tags map[string]bool\ntype Shape interface{ Area() float64 }\n" +
+		"//goequal:ignore\ntype Cache struct {\n\tm map[string]int\n}\ntype Current = Order\n"}
+	tests := []struct {
+		typeName string
+		all      bool
+		expected []string
+	}{
+		{"Order,Line", false, []string{"Line", "Order"}},
+		{"^Event.*", false, []string{"EventCreated", "EventDeleted", "Line", "Order"}},
+		{"Status, Shape", false, []string{"Shape", "Status"}},
+		{"", true, []string{"EventCreated", "EventDeleted", "Line", "Order", "Tags"}},
+		{"Status,^Event", true, []string{"EventCreated", "EventDeleted", "Line", "Order", "Status", "Tags"}},
+	}
+	for _, test := range tests {
+		g := NewGenerator("test", test.typeName, true, input, Options{All: test.all})
+		if err := g.parse(); err != nil {
+			t.Errorf("types: %q, unexpected error: %s", test.typeName, err)
+			continue
+		}
+		var names []string
+		for _, myType := range g.equalsOrder {
+			names = append(names, myType.name)
+		}
+		sort.Strings(names)
+		if !reflect.DeepEqual(names, test.expected) {
+			t.Errorf("types: %q, all: %t, expected: %v, found: %v", test.typeName, test.all, test.expected, names)
+		}
+	}
+}
+
+// TestAllAnnotated tests that the types with the generate directive of every package matched by a pattern are generated in one run.
+func TestAllAnnotated(t *testing.T) {
+	input := map[string]interface{}{
+		"test": "package test\nimport \"test/model\"\n" +
+			"//goequal:generate\ntype Invoice struct {\n\tOrder model.Order\n}\ntype Draft struct {\n\tID int\n}\n",
+		"test/model": "package model\n" +
+			"//goequal:generate\ntype Order struct {\n\tLines []Line\n}\ntype Line struct {\n\tQty int\n}\n" +
+			"type (\n\t//goequal:generate\n\tTags []string\n\tNotes []string\n)\n",
+		"test2": "package test2\n//goequal:generate\ntype Other struct {\n\tID int\n}\n",
+	}
+	g := NewGenerator("test/...", "", true, input, Options{AllAnnotated: true})
+	if err := g.parse(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	var names []string
+	for _, myType := range g.equalsOrder {
+		names = append(names, myType.pkgPath+"."+myType.name)
+	}
+	sort.Strings(names)
+	expected := []string{"test.Invoice", "test/model.Line", "test/model.Order", "test/model.Tags"}
+	if !reflect.DeepEqual(names, expected) {
+		t.Errorf("expected: %v, found: %v", expected, names)
+	}
+	// a name can be declared in many packages
+	err := NewGenerator("test/...", "Invoice", true, input, Options{AllAnnotated: true}).Generate()
+	if err == nil || err.Error() != "type Invoice can not be given by name for the 2 packages of test/..., use a regular expression" {
+		t.Errorf("expected error for a type given by name, found: %v", err)
+	}
+}
+
+func TestEqualMethodConflict(t *testing.T) {
+	input := map[string]interface{}{"test": "package test\ntype Test struct {\n\ta int\n}\nfunc (t Test) Equal(o Test) bool { return true }\n"}
+	g := NewGenerator("test", "Test", true, input, Options{Method: true})
+	err := g.Generate()
+	errs, ok := err.(Errors)
+	if !ok || len(errs) != 1 {
+		t.Fatalf("expected one error, found: %v", err)
+	}
+	if msg := "test.go:2:6: type test.Test has an Equal method, it can not have a generated one"; errs[0].Error() != msg {
+		t.Errorf("expected error: %s, found: %s", msg, errs[0])
+	}
+}
+
+// TestOutErrors tests that types of other packages which can not be compared in the output package are reported
+func TestOutErrors(t *testing.T) {
+	input := map[string]interface{}{
+		"test": "package test\ntype Test struct {\n\tName string\n\tcount int\n\tStatus status\n\tMeta struct{ id int }\n\tcache int `goequal:\"-\"`\n}\n" +
+			"type status int\n",
+		"equality": "package equality\n",
+	}
+	g := NewGenerator("test", "Test", true, input, Options{Out: "equality"})
+	err := g.Generate()
+	errs, ok := err.(Errors)
+	if !ok {
+		t.Fatalf("expected Errors, found: %v", err)
+	}
+	expected := []string{
+		"test.go:4:2: field count of test.Test is not exported, it can not be compared outside package test",
+		"test.go:6:15: field id of test.Test is not exported, it can not be compared outside package test",
+		"test.go:9:6: type status is not exported, it can not be compared outside package test",
+	}
+	if len(errs) != len(expected) {
+		t.Fatalf("expected %d errors, found: %v", len(expected), errs)
+	}
+	for i, msg := range expected {
+		if errs[i].Error() != msg {
+			t.Errorf("expected error: %s, found: %s", msg, errs[i])
+		}
+	}
+	// skipped fields are not reported, types that are not exported still are
+	err = NewGenerator("test", "Test", true, input, Options{Out: "equality", Unexported: UnexportedSkip}).Generate()
+	if errs, ok := err.(Errors); !ok || len(errs) != 1 || errs[0].Error() != expected[2] {
+		t.Errorf("expected error: %s, found: %v", expected[2], err)
+	}
+	err = NewGenerator("test", "Test", true, input, Options{Out: "equality", Unexported: "copy"}).Generate()
+	if err == nil || err.Error() != "unknown strategy copy for fields that are not exported, it can be skip or reflect" {
+		t.Errorf("expected error for unknown strategy, found: %v", err)
+	}
+}
+
+// TestCanImport tests which packages can be imported, as internal packages can be imported only from their tree
+func TestCanImport(t *testing.T) {
+	tests := []struct {
+		pkgPath, importPath string
+		expected            bool
+	}{
+		{"example.com/m/internal/equality", "example.com/m/model", true},
+		{"example.com/m/internal/equality", "example.com/m/internal/model", true},
+		{"example.com/m/internal/equality", "example.com/m/billing/internal/model", false},
+		{"example.com/m/billing/equality", "example.com/m/billing/internal/model", true},
+		{"example.com/m/billing", "example.com/m/billing/internal", true},
+		{"example.com/other", "example.com/m/internal/model", false},
+	}
+	for _, test := range tests {
+		if found := canImport(test.pkgPath, test.importPath); found != test.expected {
+			t.Errorf("package: %s, import: %s, expected: %t, found: %t", test.pkgPath, test.importPath, test.expected, found)
+		}
+	}
+}
+
+// TestNested tests paths of nested differences
+func TestNested(t *testing.T) {
+	nested := []Difference{{Path: ""}, {Path: "Qty"}, {Path: "[2]"}, {Path: `["a"].Qty`}}
+	tests := []struct {
+		path  string
+		paths []string
+	}{
+		{"", []string{"", "Qty", "[2]", `["a"].Qty`}},
+		{"Lines", []string{"Lines", "Lines.Qty", "Lines[2]", `Lines["a"].Qty`}},
+		{"Orders[3]", []string{"Orders[3]", "Orders[3].Qty", "Orders[3][2]", `Orders[3]["a"].Qty`}},
+	}
+	for _, test := range tests {
+		diffs := Nested([]Difference{{Path: "ID"}}, test.path, nested)
+		if len(diffs) != len(test.paths)+1 || diffs[0].Path != "ID" {
+			t.Errorf("path: %s, unexpected differences: %v", test.path, diffs)
+			continue
+		}
+		for i, path := range test.paths {
+			if diffs[i+1].Path != path {
+				t.Errorf("path: %s, expected: %s, found: %s", test.path, path, diffs[i+1].Path)
+			}
+		}
+	}
+}
+
+// TestChainPath tests paths of values walked by a loop
+func TestChainPath(t *testing.T) {
+	tests := []struct {
+		depth int
+		path  string
+	}{
+		{0, ""},
+		{1, "Next"},
+		{3, "Next.Next.Next"},
+	}
+	for _, test := range tests {
+		if path := ChainPath("Next", test.depth); path != test.path {
+			t.Errorf("depth: %d, expected: %s, found: %s", test.depth, test.path, path)
+		}
+	}
+}
+
+// TestContainers tests the comparisons of lists and sync maps
+func TestContainers(t *testing.T) {
+	newList := func(values ...any) *list.List {
+		l := list.New()
+		for _, v := range values {
+			l.PushBack(v)
+		}
+		return l
+	}
+	newMap := func(entries map[any]any) *sync.Map {
+		m := new(sync.Map)
+		for k, v := range entries {
+			m.Store(k, v)
+		}
+		return m
+	}
+	cloned := list.New()
+	CloneList(cloned, newList(1, []int{2}))
+	clonedMap := new(sync.Map)
+	CloneSyncMap(clonedMap, newMap(map[any]any{"a": []int{1}}))
+	tests := []struct {
+		name     string
+		equal    bool
+		expected bool
+	}{
+		{"list equal", ListEqual(newList(1, []int{2}), newList(1, []int{2})), true},
+		{"list order", ListEqual(newList(1, 2), newList(2, 1)), false},
+		{"list length", ListEqual(newList(1), newList(1, 1)), false},
+		{"list clone", ListEqual(cloned, newList(1, []int{2})), true},
+		{"map equal", SyncMapEqual(newMap(map[any]any{"a": []int{1}, "b": 2}), newMap(map[any]any{"b": 2, "a": []int{1}})), true},
+		{"map value", SyncMapEqual(newMap(map[any]any{"a": 1}), newMap(map[any]any{"a": 2})), false},
+		{"map missing key", SyncMapEqual(newMap(map[any]any{"a": 1}), newMap(map[any]any{"b": 1})), false},
+		{"map more keys", SyncMapEqual(newMap(map[any]any{"a": 1}), newMap(map[any]any{"a": 1, "b": 1})), false},
+		{"map clone", SyncMapEqual(clonedMap, newMap(map[any]any{"a": []int{1}})), true},
+	}
+	for _, test := range tests {
+		if test.equal != test.expected {
+			t.Errorf("test: %s, expected: %t, found: %t", test.name, test.expected, test.equal)
+		}
+	}
+}
+
+// registered is a type with a function registered for interface values
+type registered struct {
+	id   int
+	name string
+}
+
+// equaler is an Equaler equal to any value with the same length
+type equaler string
+
+func (e equaler) Equal(other any) bool {
+	o, ok := other.(equaler)
+	return ok && len(o) == len(e)
+}
+
+// ptrEqualer is an Equaler whose method reads the value of its pointer
+type ptrEqualer struct{ id int }
+
+func (p *ptrEqualer) Equal(other any) bool {
+	return p.id == other.(*ptrEqualer).id
+}
+
+// TestInterface tests the comparison of interface values
+func TestInterface(t *testing.T) {
+	Register(func(t1, t2 *registered) bool { return t1 == t2 || t1 != nil && t2 != nil && t1.id == t2.id })
+	RegisterValue(func(t1, t2 []registered) bool { return len(t1) == len(t2) })
+	tests := []struct {
+		name     string
+		a, b     any
+		expected bool
+	}{
+		{"nil", nil, nil, true},
+		{"one nil", nil, 1, false},
+		{"equaler", equaler("ab"), equaler("cd"), true},
+		{"equaler different", equaler("ab"), equaler("c"), false},
+		{"equaler other type", equaler("ab"), "cd", false},
+		{"other type equaler", "cd", equaler("ab"), false},
+		{"pointer equaler", &ptrEqualer{1}, &ptrEqualer{1}, true},
+		{"pointer equaler nil", (*ptrEqualer)(nil), &ptrEqualer{1}, false},
+		{"pointer equaler other nil", &ptrEqualer{1}, (*ptrEqualer)(nil), false},
+		{"pointer equaler nils", (*ptrEqualer)(nil), (*ptrEqualer)(nil), true},
+		{"registered value", registered{1, "a"}, registered{1, "b"}, true},
+		{"registered value different", registered{1, "a"}, registered{2, "a"}, false},
+		{"registered pointer", &registered{1, "a"}, &registered{1, "b"}, true},
+		{"registered nil pointers", (*registered)(nil), (*registered)(nil), true},
+		{"registered slice", []registered{{1, "a"}}, []registered{{2, "b"}}, true},
+		{"different types", registered{1, "a"}, &registered{1, "a"}, false},
+		{"deep equal", []int{1}, []int{1}, true},
+		{"deep equal different", []int{1}, []int{2}, false},
+	}
+	for _, test := range tests {
+		if equal := Interface(test.a, test.b); equal != test.expected {
+			t.Errorf("test: %s, expected: %t, found: %t", test.name, test.expected, equal)
+		}
+	}
+}
+
+func TestVisited(t *testing.T) {
+	type node struct{ next *node }
+	n1, n2 := &node{}, &node{}
+	s := []int{1, 2, 3}
+	m := map[int]int{}
+	visited := Visited{}
+	tests := []struct {
+		name     string
+		a, b     any
+		expected bool
+	}{
+		{"pointers", n1, n2, false},
+		{"pointers again", n1, n2, true},
+		{"pointers swapped", n2, n1, false},
+		{"pointer to first field", &n1.next, &n2.next, false},
+		{"slices", s, s, false},
+		{"slices again", s, s, true},
+		{"shorter slices", s[:1], s[:1], false},
+		{"maps", m, m, false},
+		{"maps again", m, m, true},
+		{"nil pointers", (*node)(nil), (*node)(nil), false},
+		{"nil pointers again", (*node)(nil), (*node)(nil), false},
+	}
+	for _, test := range tests {
+		if found := visited.Visit(test.a, test.b); found != test.expected {
+			t.Errorf("test: %s, expected: %t, found: %t", test.name, test.expected, found)
+		}
+	}
+}
+
 // TestGetNames tests getNames
+func TestFloatEqual(t *testing.T) {
+	nan := math.NaN()
+	tests := []struct {
+		name     string
+		equal    bool
+		expected bool
+	}{
+		{"abs within", AbsEqual(1.0, 1.05, 0.1), true},
+		{"abs outside", AbsEqual(1.0, 1.2, 0.1), false},
+		{"abs infinity", AbsEqual(math.Inf(1), math.Inf(1), 0), true},
+		{"abs NaN", AbsEqual(nan, nan, 1), false},
+		{"rel within", RelEqual(1000.0, 1000.5, 1e-3), true},
+		{"rel outside", RelEqual(1.0, 1.5, 1e-3), false},
+		{"ulps next", ULPsEqual(1.0, math.Nextafter(1, 2), 1), true},
+		{"ulps zeros", ULPsEqual(math.Copysign(0, -1), 0.0, 0), true},
+		{"ulps around zero", ULPsEqual(-math.SmallestNonzeroFloat64, math.SmallestNonzeroFloat64, 2), true},
+		{"ulps outside", ULPsEqual(1.0, math.Nextafter(math.Nextafter(1, 2), 2), 1), false},
+		{"ulps float32", ULPsEqual(float32(1), math.Nextafter32(1, 2), 1), true},
+		{"ulps float32 outside", ULPsEqual(float32(1), float32(1.0001), 100), false},
+		{"ulps NaN", ULPsEqual(nan, nan, 10), false},
+		{"both NaN", BothNaN(nan, -nan), true},
+		{"one NaN", BothNaN(nan, 1), false},
+	}
+	for _, test := range tests {
+		if test.equal != test.expected {
+			t.Errorf("test: %s, expected: %t, found: %t", test.name, test.expected, test.equal)
+		}
+	}
+}
+
 func TestGetNames(t *testing.T) {
 	names := []struct {
 		name         string
@@ -1426,6 +2032,53 @@ func TestGetNames(t *testing.T) {
 	}
 }
 
+// TestGetHasher tests getName and getHasher
+func TestGetHasher(t *testing.T) {
+	names := []struct {
+		name   string
+		isType bool
+		name1  string
+		hasher string
+	}{
+		{"a", false, "t.a", "h"},
+		{"at1", false, "t.at1", "h"},
+		{"A", true, "t", "h"},
+		{"(*A)", true, "(*t)", "h"},
+		{"(*(*a)[i1])[i2]", false, "(*(*t.a)[i1])[i2]", "h"},
+		{"a[key1]", false, "value11", "h1"},
+		{"a[key1][i1]", false, "value11[i1]", "h1"},
+		{"a[key1][key2]", false, "value21", "h2"},
+		{"(*a[key12])[i2]", false, "(*value121)[i2]", "h12"},
+	}
+	for _, test := range names {
+		name1, hasher := getName(test.name, test.isType), getHasher(test.name)
+		if test.name1 != name1 || test.hasher != hasher {
+			t.Errorf("expected name and hasher:\n%s %s\nfound:\n%s %s\n", test.name1, test.hasher, name1, hasher)
+		}
+	}
+}
+
+// TestGetCloneNames tests getCloneNames
+func TestGetCloneNames(t *testing.T) {
+	names := []struct {
+		name     string
+		isType   bool
+		src, dst string
+	}{
+		{"a", false, "src.a", "dst.a"},
+		{"A", true, "src", "dst"},
+		{"(*A)", true, "(*src)", "(*dst)"},
+		{"(*a[i1])", false, "(*src.a[i1])", "(*dst.a[i1])"},
+		{"a[key1][i1]", false, "value11[i1]", "value12[i1]"},
+	}
+	for _, test := range names {
+		src, dst := getCloneNames(test.name, test.isType)
+		if test.src != src || test.dst != dst {
+			t.Errorf("expected names:\n%s %s\nfound:\n%s %s\n", test.src, test.dst, src, dst)
+		}
+	}
+}
+
 // TestFindNextUsableIndex
 func TestFindNextUsableIndex(t *testing.T) {
 	names := []struct {
@@ -1459,10 +2112,44 @@ func compile(files []string, inputs []interface{}) error {
 		}
 		astFiles = append(astFiles, parsedFile)
 	}
-	config := types.Config{Importer: importer.Default(), FakeImportC: true}
+	config := types.Config{Importer: &testImporter{std: importer.Default()}, FakeImportC: true}
 	_, err := config.Check(`main`, fs, astFiles, nil)
 	if err != nil {
 		return err
 	}
 	return nil
 }
+
+// runtimeFiles are the files of this package used by generated code
+var runtimeFiles = []string{"containers.go", "difference.go", "float.go", "pointer.go", "registry.go", "visited.go"}
+
+// testImporter imports this package from the source of its runtime files, and any other package with the std importer
+type testImporter struct {
+	std   types.Importer
+	equal *types.Package
+}
+
+func (imp *testImporter) Import(path string) (*types.Package, error) {
+	if path != equalPkgPath {
+		return imp.std.Import(path)
+	}
+	if imp.equal != nil {
+		return imp.equal, nil
+	}
+	var astFiles []*ast.File
+	fs := token.NewFileSet()
+	for _, file := range runtimeFiles {
+		parsedFile, err := parser.ParseFile(fs, file, nil, 0)
+		if err != nil {
+			return nil, err
+		}
+		astFiles = append(astFiles, parsedFile)
+	}
+	config := types.Config{Importer: imp.std}
+	pkg, err := config.Check(equalPkgPath, fs, astFiles, nil)
+	if err != nil {
+		return nil, err
+	}
+	imp.equal = pkg
+	return pkg, nil
+}
@@ -13,8 +13,74 @@ type GoldenComplex struct {
 	output  map[Type]string        // what output for the generator should be
 }
 
+// goldenMethod are generated with Equal methods
+var goldenMethod = []GoldenComplex{
+	{"methodType", "X", "test", methodTypeIn, methodTypeOut},
+}
+
+// goldenDiff are generated with Diff functions
+var goldenDiff = []GoldenComplex{
+	{"diffType", "X", "test", diffTypeIn, diffTypeOut},
+	{"diffUnordered", "X", "test", diffUnorderedIn, diffUnorderedOut},
+	{"diffKeyed", "Order", "test", diffKeyedIn, diffKeyedOut},
+	{"diffChain", "Node", "test", diffChainIn, diffChainOut},
+}
+
+// goldenHash are generated with Hash functions
+var goldenHash = []GoldenComplex{
+	{"hashType", "X", "test", hashTypeIn, hashTypeOut},
+	{"hashGeneric", "X", "test", hashGenericIn, hashGenericOut},
+	{"hashUnordered", "X", "test", hashUnorderedIn, hashUnorderedOut},
+	{"hashEqualMethod", "X", "test", hashEqualMethodIn, hashEqualMethodOut},
+	{"hashStdlib", "X", "test", hashStdlibIn, hashStdlibOut},
+}
+
+// goldenClone are generated with Clone functions
+var goldenClone = []GoldenComplex{
+	{"cloneType", "X", "test", cloneTypeIn, cloneTypeOut},
+	{"cloneGeneric", "X", "test", cloneGenericIn, cloneGenericOut},
+	{"cloneStdlib", "X", "test", cloneStdlibIn, cloneStdlibOut},
+}
+
+// goldenNaN are generated with NaN values equal to each other, together with Hash functions
+var goldenNaN = []GoldenComplex{
+	{"nanEqual", "X", "test", nanEqualIn, nanEqualOut},
+}
+
+// goldenSemantic are generated with nil pointers equal to pointers to zero values, together with Hash functions
+var goldenSemantic = []GoldenComplex{
+	{"semantic", "X", "test", semanticIn, semanticOut},
+}
+
+// goldenDispatch are generated with interface values compared with the functions of their dynamic types
+var goldenDispatch = []GoldenComplex{
+	{"dispatch", "Drawing", "test", dispatchIn, dispatchOut},
+	{"dispatchStdlib", "Source", "test", dispatchStdlibIn, dispatchStdlibOut},
+}
+
+// goldenRegister are generated with functions registered for interface values
+var goldenRegister = []GoldenComplex{
+	{"register", "Event", "test", registerIn, registerOut},
+}
+
+// goldenCycleSafe are generated with cycles detected, together with Diff functions
+var goldenCycleSafe = []GoldenComplex{
+	{"cycleSafe", "Test", "test", cycleSafeIn, cycleSafeOut},
+}
+
 var goldenC = []GoldenComplex{
 	{"followType", "X", "test", followTypeIn, followTypeOut},
+	{"genericNested", "X", "test", genericNestedIn, genericNestedOut},
+	{"genericInstance", "X", "test", genericInstanceIn, genericInstanceOut},
+	{"skipTag", "X", "test", skipTagIn, skipTagOut},
+	{"skipInstanceField", "X", "test", skipInstanceFieldIn, skipInstanceFieldOut},
+	{"funcTag", "X", "test", funcTagIn, funcTagOut},
+	{"unorderedTag", "X", "test", unorderedTagIn, unorderedTagOut},
+	{"keyTag", "Order", "test", keyTagIn, keyTagOut},
+	{"toleranceTag", "X", "test", toleranceTagIn, toleranceTagOut},
+	{"semanticTag", "X", "test", semanticTagIn, semanticTagOut},
+	{"equalMethod", "X", "test", equalMethodIn, equalMethodOut},
+	{"stdlib", "X", "test", stdlibIn, stdlibOut},
 }
 
 // follow type
@@ -74,36 +140,4102 @@ func EqualY(t1, t2 Y) bool {
 `,
 }
 
-// TestGoldenC tests generated code for types that refer to other named types
-func TestGoldenC(t *testing.T) {
-	for _, test := range goldenC {
-		// I will compile the generated code for extra checking
-		g := NewGenerator(test.pkgPath, test.typ, false, test.input)
-		g.parse()
-		success, files, inputs := assertComplex(t, g, test)
-		if success {
-			files = append(files, "test.go")
-			inputs = append(inputs, test.input[test.pkgPath])
-			if err := compile(files, inputs); err != nil {
-				t.Errorf("test: %s, code not compilable: %s", test.name, err)
+// generic types referring other generic types
+var genericNestedIn = map[string]interface{}{
+	`test`: `package test
+type X[T any] struct {
+	p    Page[T]
+	next *X[T]
+}
+type Page[T any] []T
+`,
+}
+
+var genericNestedOut = map[Type]string{
+	{"Page", "test"}: `// Code generated by goequal for type: Page; DO NOT EDIT
+package test
+
+func EqualPage[T any](t1, t2 Page[T], eqT func(a, b T) bool) bool {
+	if len(t1) != len(t2) {
+		return false
+	}
+	for i1 := range t1 {
+		if !eqT(t1[i1], t2[i1]) {
+			return false
+		}
+	}
+	return true
+}
+`,
+	{"X", "test"}: `// Code generated by goequal for type: X; DO NOT EDIT
+package test
+
+func EqualX[T any](t1, t2 *X[T], eqT func(a, b T) bool) bool {
+	for {
+		if t1 == t2 {
+			return true
+		}
+		if t1 == nil || t2 == nil {
+			return false
+		}
+		if !EqualPage(t1.p, t2.p, eqT) {
+			return false
+		}
+		t1, t2 = t1.next, t2.next
+	}
+}
+`,
+}
+
+// instantiated generic types get their own functions in the package using them
+var genericInstanceIn = map[string]interface{}{
+	`test`: `package test
+
+import "test2"
+
+type User struct {
+	name string
+}
+
+type X struct {
+	e test2.Entry[string, *User]
+	l test2.List[User]
+}
+`,
+	`test2`: `package test2
+
+type Entry[K comparable, V any] struct {
+	Key   K
+	Value V
+	Next  *Entry[K, V]
+}
+
+type List[T any] []T
+`,
+}
+
+var genericInstanceOut = map[Type]string{
+	{"User", "test"}: `// Code generated by goequal for type: User; DO NOT EDIT
+package test
+
+func EqualUser(t1, t2 *User) bool {
+	if t1 == t2 {
+		return true
+	}
+	if t1 == nil || t2 == nil {
+		return false
+	}
+	if t1.name != t2.name {
+		return false
+	}
+	return true
+}
+`,
+	{"Entry_string_ptrUser", "test"}: `// Code generated by goequal for type: Entry_string_ptrUser; DO NOT EDIT
+package test
+
+import "test2"
+
+func EqualEntry_string_ptrUser(t1, t2 *test2.Entry[string, *User]) bool {
+	for {
+		if t1 == t2 {
+			return true
+		}
+		if t1 == nil || t2 == nil {
+			return false
+		}
+		if t1.Key != t2.Key {
+			return false
+		}
+		if !EqualUser(t1.Value, t2.Value) {
+			return false
+		}
+		t1, t2 = t1.Next, t2.Next
+	}
+}
+`,
+	{"List_User", "test"}: `// Code generated by goequal for type: List_User; DO NOT EDIT
+package test
+
+import "test2"
+
+func EqualList_User(t1, t2 test2.List[User]) bool {
+	if len(t1) != len(t2) {
+		return false
+	}
+	for i1 := range t1 {
+		if !EqualUser((&t1[i1]), (&t2[i1])) {
+			return false
+		}
+	}
+	return true
+}
+`,
+	{"X", "test"}: `// Code generated by goequal for type: X; DO NOT EDIT
+package test
+
+func EqualX(t1, t2 *X) bool {
+	if t1 == t2 {
+		return true
+	}
+	if t1 == nil || t2 == nil {
+		return false
+	}
+	if !EqualEntry_string_ptrUser((&t1.e), (&t2.e)) {
+		return false
+	}
+	if !EqualList_User(t1.l, t2.l) {
+		return false
+	}
+	return true
+}
+`,
+}
+
+// Equal methods instead of functions
+var methodTypeIn = map[string]interface{}{
+	`test`: `package test
+
+type Y struct {
+	a int
+}
+
+type M map[string]Y
+
+type P *Y
+
+type I interface{ F() }
+
+type Page[T any] struct {
+	items []T
+}
+
+type X struct {
+	a  Y
+	b  *Y
+	c  []Y
+	d  map[int]Y
+	e  M
+	f  *M
+	g  P
+	i  I
+	p  Page[int]
+	pp Page[Y]
+}
+`,
+}
+
+var methodTypeOut = map[Type]string{
+	{"Y", "test"}: `// Code generated by goequal for type: Y; DO NOT EDIT
+package test
+
+func (t1 *Y) Equal(t2 *Y) bool {
+	if t1 == t2 {
+		return true
+	}
+	if t1 == nil || t2 == nil {
+		return false
+	}
+	if t1.a != t2.a {
+		return false
+	}
+	return true
+}
+`,
+	{"M", "test"}: `// Code generated by goequal for type: M; DO NOT EDIT
+package test
+
+func (t1 M) Equal(t2 M) bool {
+	if len(t1) != len(t2) {
+		return false
+	}
+	for key1, value11 := range t1 {
+		if value12, ok := t2[key1]; !ok {
+			return false
+		} else {
+			if !value11.Equal(&value12) {
+				return false
 			}
 		}
+	}
+	return true
+}
+`,
+	{"P", "test"}: `// Code generated by goequal for type: P; DO NOT EDIT
+package test
 
+func EqualP(t1, t2 P) bool {
+	if !(*Y)(t1).Equal(t2) {
+		return false
 	}
+	return true
 }
+`,
+	{"I", "test"}: `// Code generated by goequal for type: I; DO NOT EDIT
+package test
 
-// assertComplex asserts that a complex test passes
-// returns success, list of files and their containing code
-func assertComplex(t *testing.T, g *Generator, test GoldenComplex) (success bool, files []string, inputs []interface{}) {
-	success = true
-	counter := 0
-	for expectedType, expectedCode := range test.output {
-		code := g.equals[expectedType]
-		if code == nil {
-			t.Errorf("expected code for type: %v", expectedType)
-			success = false
+import "github.com/gadumitrachioaiei/goequal/equal"
+
+func EqualI(t1, t2 I) bool {
+	if !equal.Interface(t1, t2) {
+		return false
+	}
+	return true
+}
+`,
+	{"Page_int", "test"}: `// Code generated by goequal for type: Page_int; DO NOT EDIT
+package test
+
+func EqualPage_int(t1, t2 *Page[int]) bool {
+	if t1 == t2 {
+		return true
+	}
+	if t1 == nil || t2 == nil {
+		return false
+	}
+	if len(t1.items) != len(t2.items) {
+		return false
+	}
+	for i1 := range t1.items {
+		if t1.items[i1] != t2.items[i1] {
+			return false
+		}
+	}
+	return true
+}
+`,
+	{"Page_Y", "test"}: `// Code generated by goequal for type: Page_Y; DO NOT EDIT
+package test
+
+func EqualPage_Y(t1, t2 *Page[Y]) bool {
+	if t1 == t2 {
+		return true
+	}
+	if t1 == nil || t2 == nil {
+		return false
+	}
+	if len(t1.items) != len(t2.items) {
+		return false
+	}
+	for i1 := range t1.items {
+		if !t1.items[i1].Equal(&t2.items[i1]) {
+			return false
+		}
+	}
+	return true
+}
+`,
+	{"X", "test"}: `// Code generated by goequal for type: X; DO NOT EDIT
+package test
+
+func (t1 *X) Equal(t2 *X) bool {
+	if t1 == t2 {
+		return true
+	}
+	if t1 == nil || t2 == nil {
+		return false
+	}
+	if !t1.a.Equal(&t2.a) {
+		return false
+	}
+	if !t1.b.Equal(t2.b) {
+		return false
+	}
+	if len(t1.c) != len(t2.c) {
+		return false
+	}
+	for i1 := range t1.c {
+		if !t1.c[i1].Equal(&t2.c[i1]) {
+			return false
+		}
+	}
+	if len(t1.d) != len(t2.d) {
+		return false
+	}
+	for key1, value11 := range t1.d {
+		if value12, ok := t2.d[key1]; !ok {
+			return false
 		} else {
-			_, calculatedCode := code.serialize()
+			if !value11.Equal(&value12) {
+				return false
+			}
+		}
+	}
+	if !t1.e.Equal(t2.e) {
+		return false
+	}
+	if t1.f != t2.f {
+		if t1.f == nil || t2.f == nil {
+			return false
+		}
+		if !(*t1.f).Equal((*t2.f)) {
+			return false
+		}
+	}
+	if !EqualP(t1.g, t2.g) {
+		return false
+	}
+	if !EqualI(t1.i, t2.i) {
+		return false
+	}
+	if !EqualPage_int((&t1.p), (&t2.p)) {
+		return false
+	}
+	if !EqualPage_Y((&t1.pp), (&t2.pp)) {
+		return false
+	}
+	return true
+}
+`,
+}
+
+// Diff functions generated alongside Equal functions
+var diffTypeIn = map[string]interface{}{
+	`test`: `package test
+
+type Y struct {
+	Qty int
+}
+
+type L map[string]Y
+
+type X struct {
+	Orders []L
+	Note   *string
+	ID     int
+}
+`,
+}
+
+var diffTypeOut = map[Type]string{
+	{"Y", "test"}: `// Code generated by goequal for type: Y; DO NOT EDIT
+package test
+
+import "fmt"
+import "github.com/gadumitrachioaiei/goequal/equal"
+
+func EqualY(t1, t2 *Y) bool {
+	if t1 == t2 {
+		return true
+	}
+	if t1 == nil || t2 == nil {
+		return false
+	}
+	if t1.Qty != t2.Qty {
+		return false
+	}
+	return true
+}
+
+func DiffY(t1, t2 *Y) []equal.Difference {
+	if t1 == t2 {
+		return nil
+	}
+	if t1 == nil || t2 == nil {
+		return []equal.Difference{{Value1: fmt.Sprint(t1), Value2: fmt.Sprint(t2), Reason: equal.NilValue}}
+	}
+	var diffs []equal.Difference
+	if t1.Qty != t2.Qty {
+		diffs = append(diffs, equal.Difference{Path: "Qty", Value1: fmt.Sprint(t1.Qty), Value2: fmt.Sprint(t2.Qty), Reason: equal.DifferentValues})
+	}
+	return diffs
+}
+`,
+	{"L", "test"}: `// Code generated by goequal for type: L; DO NOT EDIT
+package test
+
+import "fmt"
+import "github.com/gadumitrachioaiei/goequal/equal"
+
+func EqualL(t1, t2 L) bool {
+	if len(t1) != len(t2) {
+		return false
+	}
+	for key1, value11 := range t1 {
+		if value12, ok := t2[key1]; !ok {
+			return false
+		} else {
+			if !EqualY((&value11), (&value12)) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func DiffL(t1, t2 L) []equal.Difference {
+	var diffs []equal.Difference
+	for key1, value11 := range t1 {
+		if value12, ok := t2[key1]; !ok {
+			diffs = append(diffs, equal.Difference{Path: fmt.Sprintf("[%#v]", key1), Value1: fmt.Sprint(value11), Value2: "", Reason: equal.MissingKey})
+		} else {
+			diffs = equal.Nested(diffs, fmt.Sprintf("[%#v]", key1), DiffY((&value11), (&value12)))
+		}
+	}
+	for key1, value12 := range t2 {
+		if _, ok := t1[key1]; !ok {
+			diffs = append(diffs, equal.Difference{Path: fmt.Sprintf("[%#v]", key1), Value1: "", Value2: fmt.Sprint(value12), Reason: equal.MissingKey})
+		}
+	}
+	return diffs
+}
+`,
+	{"X", "test"}: `// Code generated by goequal for type: X; DO NOT EDIT
+package test
+
+import "fmt"
+import "github.com/gadumitrachioaiei/goequal/equal"
+
+func EqualX(t1, t2 *X) bool {
+	if t1 == t2 {
+		return true
+	}
+	if t1 == nil || t2 == nil {
+		return false
+	}
+	if len(t1.Orders) != len(t2.Orders) {
+		return false
+	}
+	for i1 := range t1.Orders {
+		if !EqualL(t1.Orders[i1], t2.Orders[i1]) {
+			return false
+		}
+	}
+	if t1.Note != t2.Note {
+		if t1.Note == nil || t2.Note == nil {
+			return false
+		}
+		if (*t1.Note) != (*t2.Note) {
+			return false
+		}
+	}
+	if t1.ID != t2.ID {
+		return false
+	}
+	return true
+}
+
+func DiffX(t1, t2 *X) []equal.Difference {
+	if t1 == t2 {
+		return nil
+	}
+	if t1 == nil || t2 == nil {
+		return []equal.Difference{{Value1: fmt.Sprint(t1), Value2: fmt.Sprint(t2), Reason: equal.NilValue}}
+	}
+	var diffs []equal.Difference
+	if len(t1.Orders) != len(t2.Orders) {
+		diffs = append(diffs, equal.Difference{Path: "Orders", Value1: fmt.Sprint(len(t1.Orders)), Value2: fmt.Sprint(len(t2.Orders)), Reason: equal.DifferentLengths})
+	} else {
+		for i1 := range t1.Orders {
+			diffs = equal.Nested(diffs, fmt.Sprintf("Orders[%d]", i1), DiffL(t1.Orders[i1], t2.Orders[i1]))
+		}
+	}
+	if t1.Note != t2.Note {
+		if t1.Note == nil || t2.Note == nil {
+			diffs = append(diffs, equal.Difference{Path: "Note", Value1: fmt.Sprint(t1.Note), Value2: fmt.Sprint(t2.Note), Reason: equal.NilValue})
+		} else {
+			if (*t1.Note) != (*t2.Note) {
+				diffs = append(diffs, equal.Difference{Path: "Note", Value1: fmt.Sprint((*t1.Note)), Value2: fmt.Sprint((*t2.Note)), Reason: equal.DifferentValues})
+			}
+		}
+	}
+	if t1.ID != t2.ID {
+		diffs = append(diffs, equal.Difference{Path: "ID", Value1: fmt.Sprint(t1.ID), Value2: fmt.Sprint(t2.ID), Reason: equal.DifferentValues})
+	}
+	return diffs
+}
+`,
+}
+
+// hash functions
+var hashTypeIn = map[string]interface{}{
+	`test`: `package test
+type X struct {
+	a  Y
+	b  *Y
+	c  map[string][]int
+	d  []byte
+	e  *int
+	f  interface{}
+	g  func()
+	p  Page[int]
+	ps Page[Y]
+}
+type Y struct {
+	a [2]int
+	b map[Y2]struct{}
+}
+type Y2 struct {
+	a string
+}
+type Page[T any] []T
+`,
+}
+
+var hashTypeOut = map[Type]string{
+	{"Y", "test"}: `// Code generated by goequal for type: Y; DO NOT EDIT
+package test
+
+import "hash/maphash"
+
+func EqualY(t1, t2 *Y) bool {
+	if t1 == t2 {
+		return true
+	}
+	if t1 == nil || t2 == nil {
+		return false
+	}
+	if t1.a != t2.a {
+		return false
+	}
+	if len(t1.b) != len(t2.b) {
+		return false
+	}
+	for key1 := range t1.b {
+		if _, ok := t2.b[key1]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func HashY(t *Y, h *maphash.Hash) {
+	if t == nil {
+		h.WriteByte(0)
+		return
+	}
+	h.WriteByte(1)
+	maphash.WriteComparable(h, t.a)
+	{
+		var sum1 uint64
+		h1 := new(maphash.Hash)
+		h1.SetSeed(h.Seed())
+		for key1 := range t.b {
+			h1.Reset()
+			maphash.WriteComparable(h1, key1)
+			sum1 += h1.Sum64()
+		}
+		maphash.WriteComparable(h, sum1)
+	}
+}
+`,
+	{"Page_int", "test"}: `// Code generated by goequal for type: Page_int; DO NOT EDIT
+package test
+
+import "hash/maphash"
+
+func EqualPage_int(t1, t2 Page[int]) bool {
+	if len(t1) != len(t2) {
+		return false
+	}
+	for i1 := range t1 {
+		if t1[i1] != t2[i1] {
+			return false
+		}
+	}
+	return true
+}
+
+func HashPage_int(t Page[int], h *maphash.Hash) {
+	maphash.WriteComparable(h, len(t))
+	for i1 := range t {
+		maphash.WriteComparable(h, t[i1])
+	}
+}
+`,
+	{"Page_Y", "test"}: `// Code generated by goequal for type: Page_Y; DO NOT EDIT
+package test
+
+import "hash/maphash"
+
+func EqualPage_Y(t1, t2 Page[Y]) bool {
+	if len(t1) != len(t2) {
+		return false
+	}
+	for i1 := range t1 {
+		if !EqualY((&t1[i1]), (&t2[i1])) {
+			return false
+		}
+	}
+	return true
+}
+
+func HashPage_Y(t Page[Y], h *maphash.Hash) {
+	maphash.WriteComparable(h, len(t))
+	for i1 := range t {
+		HashY((&t[i1]), h)
+	}
+}
+`,
+	{"X", "test"}: `// Code generated by goequal for type: X; DO NOT EDIT
+package test
+
+import "bytes"
+import "github.com/gadumitrachioaiei/goequal/equal"
+import "hash/maphash"
+
+func EqualX(t1, t2 *X) bool {
+	if t1 == t2 {
+		return true
+	}
+	if t1 == nil || t2 == nil {
+		return false
+	}
+	if !EqualY((&t1.a), (&t2.a)) {
+		return false
+	}
+	if !EqualY(t1.b, t2.b) {
+		return false
+	}
+	if len(t1.c) != len(t2.c) {
+		return false
+	}
+	for key1, value11 := range t1.c {
+		if value12, ok := t2.c[key1]; !ok {
+			return false
+		} else {
+			if len(value11) != len(value12) {
+				return false
+			}
+			for i1 := range value11 {
+				if value11[i1] != value12[i1] {
+					return false
+				}
+			}
+		}
+	}
+	if !bytes.Equal(t1.d, t2.d) {
+		return false
+	}
+	if t1.e != t2.e {
+		if t1.e == nil || t2.e == nil {
+			return false
+		}
+		if (*t1.e) != (*t2.e) {
+			return false
+		}
+	}
+	if !equal.Interface(t1.f, t2.f) {
+		return false
+	}
+	if !EqualPage_int(t1.p, t2.p) {
+		return false
+	}
+	if !EqualPage_Y(t1.ps, t2.ps) {
+		return false
+	}
+	return true
+}
+
+func HashX(t *X, h *maphash.Hash) {
+	if t == nil {
+		h.WriteByte(0)
+		return
+	}
+	h.WriteByte(1)
+	HashY((&t.a), h)
+	HashY(t.b, h)
+	{
+		var sum1 uint64
+		h1 := new(maphash.Hash)
+		h1.SetSeed(h.Seed())
+		for key1, value11 := range t.c {
+			h1.Reset()
+			maphash.WriteComparable(h1, key1)
+			maphash.WriteComparable(h1, len(value11))
+			for i1 := range value11 {
+				maphash.WriteComparable(h1, value11[i1])
+			}
+			sum1 += h1.Sum64()
+		}
+		maphash.WriteComparable(h, sum1)
+	}
+	maphash.WriteComparable(h, len(t.d))
+	h.Write(t.d)
+	if t.e == nil {
+		h.WriteByte(0)
+	} else {
+		h.WriteByte(1)
+		maphash.WriteComparable(h, (*t.e))
+	}
+	HashPage_int(t.p, h)
+	HashPage_Y(t.ps, h)
+}
+`,
+}
+
+// generic hash functions
+var hashGenericIn = map[string]interface{}{
+	`test`: `package test
+type X[K comparable, V any] struct {
+	items  Page[V]
+	keys   Page[K]
+	nested Page[[]V]
+	index  map[K]V
+}
+type Page[T any] []T
+`,
+}
+
+var hashGenericOut = map[Type]string{
+	{"Page", "test"}: `// Code generated by goequal for type: Page; DO NOT EDIT
+package test
+
+import "hash/maphash"
+
+func EqualPage[T any](t1, t2 Page[T], eqT func(a, b T) bool) bool {
+	if len(t1) != len(t2) {
+		return false
+	}
+	for i1 := range t1 {
+		if !eqT(t1[i1], t2[i1]) {
+			return false
+		}
+	}
+	return true
+}
+
+func HashPage[T any](t Page[T], h *maphash.Hash, hashT func(*maphash.Hash, T)) {
+	maphash.WriteComparable(h, len(t))
+	for i1 := range t {
+		hashT(h, t[i1])
+	}
+}
+`,
+	{"X", "test"}: `// Code generated by goequal for type: X; DO NOT EDIT
+package test
+
+import "hash/maphash"
+
+func EqualX[K comparable, V any](t1, t2 *X[K, V], eqV func(a, b V) bool) bool {
+	if t1 == t2 {
+		return true
+	}
+	if t1 == nil || t2 == nil {
+		return false
+	}
+	if !EqualPage(t1.items, t2.items, eqV) {
+		return false
+	}
+	if !EqualPage(t1.keys, t2.keys, func(t1, t2 K) bool {
+		if t1 != t2 {
+			return false
+		}
+		return true
+	}) {
+		return false
+	}
+	if !EqualPage(t1.nested, t2.nested, func(t1, t2 []V) bool {
+		if len(t1) != len(t2) {
+			return false
+		}
+		for i1 := range t1 {
+			if !eqV(t1[i1], t2[i1]) {
+				return false
+			}
+		}
+		return true
+	}) {
+		return false
+	}
+	if len(t1.index) != len(t2.index) {
+		return false
+	}
+	for key1, value11 := range t1.index {
+		if value12, ok := t2.index[key1]; !ok {
+			return false
+		} else {
+			if !eqV(value11, value12) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func HashX[K comparable, V any](t *X[K, V], h *maphash.Hash, hashV func(*maphash.Hash, V)) {
+	if t == nil {
+		h.WriteByte(0)
+		return
+	}
+	h.WriteByte(1)
+	HashPage(t.items, h, hashV)
+	HashPage(t.keys, h, func(h *maphash.Hash, t K) {
+		maphash.WriteComparable(h, t)
+	})
+	HashPage(t.nested, h, func(h *maphash.Hash, t []V) {
+		maphash.WriteComparable(h, len(t))
+		for i1 := range t {
+			hashV(h, t[i1])
+		}
+	})
+	{
+		var sum1 uint64
+		h1 := new(maphash.Hash)
+		h1.SetSeed(h.Seed())
+		for key1, value11 := range t.index {
+			h1.Reset()
+			maphash.WriteComparable(h1, key1)
+			hashV(h1, value11)
+			sum1 += h1.Sum64()
+		}
+		maphash.WriteComparable(h, sum1)
+	}
+}
+`,
+}
+
+// clone functions
+var cloneTypeIn = map[string]interface{}{
+	`test`: `package test
+type X struct {
+	a  Y
+	b  *Y
+	c  map[string][]int
+	d  []byte
+	e  *int
+	f  interface{}
+	g  func()
+	p  Page[int]
+	ps Page[Y]
+}
+type Y struct {
+	a [2]int
+	b map[Y2]struct{}
+}
+type Y2 struct {
+	a string
+}
+type Page[T any] []T
+`,
+}
+
+var cloneTypeOut = map[Type]string{
+	{"Y", "test"}: `// Code generated by goequal for type: Y; DO NOT EDIT
+package test
+
+func EqualY(t1, t2 *Y) bool {
+	if t1 == t2 {
+		return true
+	}
+	if t1 == nil || t2 == nil {
+		return false
+	}
+	if t1.a != t2.a {
+		return false
+	}
+	if len(t1.b) != len(t2.b) {
+		return false
+	}
+	for key1 := range t1.b {
+		if _, ok := t2.b[key1]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func CloneY(src *Y) *Y {
+	if src == nil {
+		return nil
+	}
+	dst := new(Y)
+	dst.a = src.a
+	if src.b != nil {
+		dst.b = make(map[Y2]struct{}, len(src.b))
+		for key1 := range src.b {
+			var value12 struct{}
+			dst.b[key1] = value12
+		}
+	}
+	return dst
+}
+`,
+	{"Page_int", "test"}: `// Code generated by goequal for type: Page_int; DO NOT EDIT
+package test
+
+func EqualPage_int(t1, t2 Page[int]) bool {
+	if len(t1) != len(t2) {
+		return false
+	}
+	for i1 := range t1 {
+		if t1[i1] != t2[i1] {
+			return false
+		}
+	}
+	return true
+}
+
+func ClonePage_int(src Page[int]) Page[int] {
+	var dst Page[int]
+	if src != nil {
+		dst = make([]int, len(src))
+		copy(dst, src)
+	}
+	return dst
+}
+`,
+	{"Page_Y", "test"}: `// Code generated by goequal for type: Page_Y; DO NOT EDIT
+package test
+
+func EqualPage_Y(t1, t2 Page[Y]) bool {
+	if len(t1) != len(t2) {
+		return false
+	}
+	for i1 := range t1 {
+		if !EqualY((&t1[i1]), (&t2[i1])) {
+			return false
+		}
+	}
+	return true
+}
+
+func ClonePage_Y(src Page[Y]) Page[Y] {
+	var dst Page[Y]
+	if src != nil {
+		dst = make([]Y, len(src))
+		for i1 := range src {
+			dst[i1] = *CloneY((&src[i1]))
+		}
+	}
+	return dst
+}
+`,
+	{"X", "test"}: `// Code generated by goequal for type: X; DO NOT EDIT
+package test
+
+import "bytes"
+import "github.com/gadumitrachioaiei/goequal/equal"
+
+func EqualX(t1, t2 *X) bool {
+	if t1 == t2 {
+		return true
+	}
+	if t1 == nil || t2 == nil {
+		return false
+	}
+	if !EqualY((&t1.a), (&t2.a)) {
+		return false
+	}
+	if !EqualY(t1.b, t2.b) {
+		return false
+	}
+	if len(t1.c) != len(t2.c) {
+		return false
+	}
+	for key1, value11 := range t1.c {
+		if value12, ok := t2.c[key1]; !ok {
+			return false
+		} else {
+			if len(value11) != len(value12) {
+				return false
+			}
+			for i1 := range value11 {
+				if value11[i1] != value12[i1] {
+					return false
+				}
+			}
+		}
+	}
+	if !bytes.Equal(t1.d, t2.d) {
+		return false
+	}
+	if t1.e != t2.e {
+		if t1.e == nil || t2.e == nil {
+			return false
+		}
+		if (*t1.e) != (*t2.e) {
+			return false
+		}
+	}
+	if !equal.Interface(t1.f, t2.f) {
+		return false
+	}
+	if !EqualPage_int(t1.p, t2.p) {
+		return false
+	}
+	if !EqualPage_Y(t1.ps, t2.ps) {
+		return false
+	}
+	return true
+}
+
+func CloneX(src *X) *X {
+	if src == nil {
+		return nil
+	}
+	dst := new(X)
+	dst.a = *CloneY((&src.a))
+	dst.b = CloneY(src.b)
+	if src.c != nil {
+		dst.c = make(map[string][]int, len(src.c))
+		for key1, value11 := range src.c {
+			var value12 []int
+			if value11 != nil {
+				value12 = make([]int, len(value11))
+				copy(value12, value11)
+			}
+			dst.c[key1] = value12
+		}
+	}
+	if src.d != nil {
+		dst.d = make([]byte, len(src.d))
+		copy(dst.d, src.d)
+	}
+	if src.e != nil {
+		dst.e = new(int)
+		(*dst.e) = (*src.e)
+	}
+	dst.f = src.f
+	dst.p = ClonePage_int(src.p)
+	dst.ps = ClonePage_Y(src.ps)
+	return dst
+}
+`,
+}
+
+// generic clone functions
+var cloneGenericIn = map[string]interface{}{
+	`test`: `package test
+type X[K comparable, V any] struct {
+	items  Page[V]
+	keys   Page[K]
+	nested Page[[]V]
+	index  map[K]V
+}
+type Page[T any] []T
+`,
+}
+
+var cloneGenericOut = map[Type]string{
+	{"Page", "test"}: `// Code generated by goequal for type: Page; DO NOT EDIT
+package test
+
+func EqualPage[T any](t1, t2 Page[T], eqT func(a, b T) bool) bool {
+	if len(t1) != len(t2) {
+		return false
+	}
+	for i1 := range t1 {
+		if !eqT(t1[i1], t2[i1]) {
+			return false
+		}
+	}
+	return true
+}
+
+func ClonePage[T any](src Page[T], cloneT func(T) T) Page[T] {
+	var dst Page[T]
+	if src != nil {
+		dst = make([]T, len(src))
+		for i1 := range src {
+			dst[i1] = cloneT(src[i1])
+		}
+	}
+	return dst
+}
+`,
+	{"X", "test"}: `// Code generated by goequal for type: X; DO NOT EDIT
+package test
+
+func EqualX[K comparable, V any](t1, t2 *X[K, V], eqV func(a, b V) bool) bool {
+	if t1 == t2 {
+		return true
+	}
+	if t1 == nil || t2 == nil {
+		return false
+	}
+	if !EqualPage(t1.items, t2.items, eqV) {
+		return false
+	}
+	if !EqualPage(t1.keys, t2.keys, func(t1, t2 K) bool {
+		if t1 != t2 {
+			return false
+		}
+		return true
+	}) {
+		return false
+	}
+	if !EqualPage(t1.nested, t2.nested, func(t1, t2 []V) bool {
+		if len(t1) != len(t2) {
+			return false
+		}
+		for i1 := range t1 {
+			if !eqV(t1[i1], t2[i1]) {
+				return false
+			}
+		}
+		return true
+	}) {
+		return false
+	}
+	if len(t1.index) != len(t2.index) {
+		return false
+	}
+	for key1, value11 := range t1.index {
+		if value12, ok := t2.index[key1]; !ok {
+			return false
+		} else {
+			if !eqV(value11, value12) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func CloneX[K comparable, V any](src *X[K, V], cloneV func(V) V) *X[K, V] {
+	if src == nil {
+		return nil
+	}
+	dst := new(X[K, V])
+	dst.items = ClonePage(src.items, cloneV)
+	dst.keys = ClonePage(src.keys, func(src K) K {
+		var dst K
+		dst = src
+		return dst
+	})
+	dst.nested = ClonePage(src.nested, func(src []V) []V {
+		var dst []V
+		if src != nil {
+			dst = make([]V, len(src))
+			for i1 := range src {
+				dst[i1] = cloneV(src[i1])
+			}
+		}
+		return dst
+	})
+	if src.index != nil {
+		dst.index = make(map[K]V, len(src.index))
+		for key1, value11 := range src.index {
+			var value12 V
+			value12 = cloneV(value11)
+			dst.index[key1] = value12
+		}
+	}
+	return dst
+}
+`,
+}
+
+// fields with the goequal tag and types with the ignore directive are skipped
+var skipTagIn = map[string]interface{}{
+	`test`: `package test
+
+import "sync"
+
+type X struct {
+	a         int
+	updatedAt int64 ` + "`" + `json:"u" goequal:"-"` + "`" + `
+	mu        sync.Mutex ` + "`" + `goequal:"-"` + "`" + `
+	cache     map[string]*Y ` + "`" + `goequal:"-"` + "`" + `
+	y         Y
+	c         Cache
+	cp        *Cache
+	cs        []Cache
+	Y2        ` + "`" + `goequal:"-"` + "`" + `
+}
+
+type Y struct {
+	b []int
+}
+
+type Y2 struct {
+	b []int
+}
+
+//goequal:ignore
+type Cache struct {
+	m map[string]int
+}
+`,
+}
+
+var skipTagOut = map[Type]string{
+	{"Y", "test"}: `// Code generated by goequal for type: Y; DO NOT EDIT
+package test
+
+func EqualY(t1, t2 *Y) bool {
+	if t1 == t2 {
+		return true
+	}
+	if t1 == nil || t2 == nil {
+		return false
+	}
+	if len(t1.b) != len(t2.b) {
+		return false
+	}
+	for i1 := range t1.b {
+		if t1.b[i1] != t2.b[i1] {
+			return false
+		}
+	}
+	return true
+}
+`,
+	{"X", "test"}: `// Code generated by goequal for type: X; DO NOT EDIT
+package test
+
+func EqualX(t1, t2 *X) bool {
+	if t1 == t2 {
+		return true
+	}
+	if t1 == nil || t2 == nil {
+		return false
+	}
+	if t1.a != t2.a {
+		return false
+	}
+	if !EqualY((&t1.y), (&t2.y)) {
+		return false
+	}
+	if t1.cp != t2.cp {
+		if t1.cp == nil || t2.cp == nil {
+			return false
+		}
+	}
+	if len(t1.cs) != len(t2.cs) {
+		return false
+	}
+	return true
+}
+`,
+}
+
+// skipped unexported fields of instances from other packages are not reported
+var skipInstanceFieldIn = map[string]interface{}{
+	`test`: `package test
+
+import "test2"
+
+type X struct {
+	e test2.Entry[int]
+}
+`,
+	`test2`: `package test2
+
+type Entry[T any] struct {
+	Value T
+	hits  int ` + "`" + `goequal:"-"` + "`" + `
+}
+`,
+}
+
+var skipInstanceFieldOut = map[Type]string{
+	{"Entry_int", "test"}: `// Code generated by goequal for type: Entry_int; DO NOT EDIT
+package test
+
+import "test2"
+
+func EqualEntry_int(t1, t2 *test2.Entry[int]) bool {
+	if t1 == t2 {
+		return true
+	}
+	if t1 == nil || t2 == nil {
+		return false
+	}
+	if t1.Value != t2.Value {
+		return false
+	}
+	return true
+}
+`,
+	{"X", "test"}: `// Code generated by goequal for type: X; DO NOT EDIT
+package test
+
+func EqualX(t1, t2 *X) bool {
+	if t1 == t2 {
+		return true
+	}
+	if t1 == nil || t2 == nil {
+		return false
+	}
+	if !EqualEntry_int((&t1.e), (&t2.e)) {
+		return false
+	}
+	return true
+}
+`,
+}
+
+// fields with the goequal func tag are compared by the named function
+var funcTagIn = map[string]interface{}{
+	`test`: `package test
+
+import "test2"
+
+type X struct {
+	a []int    ` + "`" + `goequal:"func=sameOrder"` + "`" + `
+	b []string ` + "`" + `goequal:"func=test2.SameStrings"` + "`" + `
+	c test2.Y  ` + "`" + `goequal:"func=test2.SameY"` + "`" + `
+	d int
+}
+
+func sameOrder(a, b []int) bool {
+	return len(a) == len(b)
+}
+`,
+	`test2`: `package test2
+
+type Y struct {
+	a int
+}
+
+func SameStrings(a, b []string) bool {
+	return len(a) == len(b)
+}
+
+func SameY(a, b Y) bool { return true }
+`,
+}
+
+var funcTagOut = map[Type]string{
+	{"X", "test"}: `// Code generated by goequal for type: X; DO NOT EDIT
+package test
+
+import "test2"
+
+func EqualX(t1, t2 *X) bool {
+	if t1 == t2 {
+		return true
+	}
+	if t1 == nil || t2 == nil {
+		return false
+	}
+	if !sameOrder(t1.a, t2.a) {
+		return false
+	}
+	if !test2.SameStrings(t1.b, t2.b) {
+		return false
+	}
+	if !test2.SameY(t1.c, t2.c) {
+		return false
+	}
+	if t1.d != t2.d {
+		return false
+	}
+	return true
+}
+`,
+}
+
+// TestGoldenC tests generated code for types that refer to other named types
+// unordered tag
+var unorderedTagIn = map[string]interface{}{
+	`test`: `package test
+
+type Y struct {
+	a []int
+}
+
+type Tags []string
+
+type X struct {
+	tags  []string         ` + "`" + `goequal:"unordered"` + "`" + `
+	named Tags             ` + "`" + `goequal:"unordered"` + "`" + `
+	ys    []Y              ` + "`" + `goequal:"unordered"` + "`" + `
+	byKey map[string][]int ` + "`" + `goequal:"unordered"` + "`" + `
+}
+`,
+}
+
+var unorderedTagOut = map[Type]string{
+	{"Y", "test"}: `// Code generated by goequal for type: Y; DO NOT EDIT
+package test
+
+func EqualY(t1, t2 *Y) bool {
+	if t1 == t2 {
+		return true
+	}
+	if t1 == nil || t2 == nil {
+		return false
+	}
+	if len(t1.a) != len(t2.a) {
+		return false
+	}
+	for i1 := range t1.a {
+		if t1.a[i1] != t2.a[i1] {
+			return false
+		}
+	}
+	return true
+}
+`,
+	{"X", "test"}: `// Code generated by goequal for type: X; DO NOT EDIT
+package test
+
+func EqualX(t1, t2 *X) bool {
+	if t1 == t2 {
+		return true
+	}
+	if t1 == nil || t2 == nil {
+		return false
+	}
+	if len(t1.tags) != len(t2.tags) {
+		return false
+	}
+	{
+		counts1 := make(map[string]int, len(t1.tags))
+		for i1 := range t1.tags {
+			counts1[t1.tags[i1]]++
+		}
+		for i1 := range t2.tags {
+			if counts1[t2.tags[i1]] == 0 {
+				return false
+			}
+			counts1[t2.tags[i1]]--
+		}
+	}
+	if len(t1.named) != len(t2.named) {
+		return false
+	}
+	{
+		counts1 := make(map[string]int, len(t1.named))
+		for i1 := range t1.named {
+			counts1[t1.named[i1]]++
+		}
+		for i1 := range t2.named {
+			if counts1[t2.named[i1]] == 0 {
+				return false
+			}
+			counts1[t2.named[i1]]--
+		}
+	}
+	if len(t1.ys) != len(t2.ys) {
+		return false
+	}
+	{
+		eq1 := func(t1, t2 Y) bool {
+			if !EqualY((&t1), (&t2)) {
+				return false
+			}
+			return true
+		}
+		matched1 := make([]bool, len(t2.ys))
+		for i1 := range t1.ys {
+			found1 := false
+			for j1 := range t2.ys {
+				if !matched1[j1] && eq1(t1.ys[i1], t2.ys[j1]) {
+					matched1[j1] = true
+					found1 = true
+					break
+				}
+			}
+			if !found1 {
+				return false
+			}
+		}
+	}
+	if len(t1.byKey) != len(t2.byKey) {
+		return false
+	}
+	for key1, value11 := range t1.byKey {
+		if value12, ok := t2.byKey[key1]; !ok {
+			return false
+		} else {
+			if len(value11) != len(value12) {
+				return false
+			}
+			{
+				counts1 := make(map[int]int, len(value11))
+				for i1 := range value11 {
+					counts1[value11[i1]]++
+				}
+				for i1 := range value12 {
+					if counts1[value12[i1]] == 0 {
+						return false
+					}
+					counts1[value12[i1]]--
+				}
+			}
+		}
+	}
+	return true
+}
+`,
+}
+
+// diff of unordered slices
+var diffUnorderedIn = map[string]interface{}{
+	`test`: `package test
+
+type Y struct {
+	a []int
+}
+
+type Tags []string
+
+type X struct {
+	tags  []string         ` + "`" + `goequal:"unordered"` + "`" + `
+	named Tags             ` + "`" + `goequal:"unordered"` + "`" + `
+	ys    []Y              ` + "`" + `goequal:"unordered"` + "`" + `
+	byKey map[string][]int ` + "`" + `goequal:"unordered"` + "`" + `
+}
+`,
+}
+
+var diffUnorderedOut = map[Type]string{
+	{"Y", "test"}: `// Code generated by goequal for type: Y; DO NOT EDIT
+package test
+
+import "fmt"
+import "github.com/gadumitrachioaiei/goequal/equal"
+
+func EqualY(t1, t2 *Y) bool {
+	if t1 == t2 {
+		return true
+	}
+	if t1 == nil || t2 == nil {
+		return false
+	}
+	if len(t1.a) != len(t2.a) {
+		return false
+	}
+	for i1 := range t1.a {
+		if t1.a[i1] != t2.a[i1] {
+			return false
+		}
+	}
+	return true
+}
+
+func DiffY(t1, t2 *Y) []equal.Difference {
+	if t1 == t2 {
+		return nil
+	}
+	if t1 == nil || t2 == nil {
+		return []equal.Difference{{Value1: fmt.Sprint(t1), Value2: fmt.Sprint(t2), Reason: equal.NilValue}}
+	}
+	var diffs []equal.Difference
+	if len(t1.a) != len(t2.a) {
+		diffs = append(diffs, equal.Difference{Path: "a", Value1: fmt.Sprint(len(t1.a)), Value2: fmt.Sprint(len(t2.a)), Reason: equal.DifferentLengths})
+	} else {
+		for i1 := range t1.a {
+			if t1.a[i1] != t2.a[i1] {
+				diffs = append(diffs, equal.Difference{Path: fmt.Sprintf("a[%d]", i1), Value1: fmt.Sprint(t1.a[i1]), Value2: fmt.Sprint(t2.a[i1]), Reason: equal.DifferentValues})
+			}
+		}
+	}
+	return diffs
+}
+`,
+	{"X", "test"}: `// Code generated by goequal for type: X; DO NOT EDIT
+package test
+
+import "fmt"
+import "github.com/gadumitrachioaiei/goequal/equal"
+
+func EqualX(t1, t2 *X) bool {
+	if t1 == t2 {
+		return true
+	}
+	if t1 == nil || t2 == nil {
+		return false
+	}
+	if len(t1.tags) != len(t2.tags) {
+		return false
+	}
+	{
+		counts1 := make(map[string]int, len(t1.tags))
+		for i1 := range t1.tags {
+			counts1[t1.tags[i1]]++
+		}
+		for i1 := range t2.tags {
+			if counts1[t2.tags[i1]] == 0 {
+				return false
+			}
+			counts1[t2.tags[i1]]--
+		}
+	}
+	if len(t1.named) != len(t2.named) {
+		return false
+	}
+	{
+		counts1 := make(map[string]int, len(t1.named))
+		for i1 := range t1.named {
+			counts1[t1.named[i1]]++
+		}
+		for i1 := range t2.named {
+			if counts1[t2.named[i1]] == 0 {
+				return false
+			}
+			counts1[t2.named[i1]]--
+		}
+	}
+	if len(t1.ys) != len(t2.ys) {
+		return false
+	}
+	{
+		eq1 := func(t1, t2 Y) bool {
+			if !EqualY((&t1), (&t2)) {
+				return false
+			}
+			return true
+		}
+		matched1 := make([]bool, len(t2.ys))
+		for i1 := range t1.ys {
+			found1 := false
+			for j1 := range t2.ys {
+				if !matched1[j1] && eq1(t1.ys[i1], t2.ys[j1]) {
+					matched1[j1] = true
+					found1 = true
+					break
+				}
+			}
+			if !found1 {
+				return false
+			}
+		}
+	}
+	if len(t1.byKey) != len(t2.byKey) {
+		return false
+	}
+	for key1, value11 := range t1.byKey {
+		if value12, ok := t2.byKey[key1]; !ok {
+			return false
+		} else {
+			if len(value11) != len(value12) {
+				return false
+			}
+			{
+				counts1 := make(map[int]int, len(value11))
+				for i1 := range value11 {
+					counts1[value11[i1]]++
+				}
+				for i1 := range value12 {
+					if counts1[value12[i1]] == 0 {
+						return false
+					}
+					counts1[value12[i1]]--
+				}
+			}
+		}
+	}
+	return true
+}
+
+func DiffX(t1, t2 *X) []equal.Difference {
+	if t1 == t2 {
+		return nil
+	}
+	if t1 == nil || t2 == nil {
+		return []equal.Difference{{Value1: fmt.Sprint(t1), Value2: fmt.Sprint(t2), Reason: equal.NilValue}}
+	}
+	var diffs []equal.Difference
+	if len(t1.tags) != len(t2.tags) {
+		diffs = append(diffs, equal.Difference{Path: "tags", Value1: fmt.Sprint(len(t1.tags)), Value2: fmt.Sprint(len(t2.tags)), Reason: equal.DifferentLengths})
+	} else {
+		{
+			counts1 := make(map[string]int, len(t1.tags))
+			for i1 := range t1.tags {
+				counts1[t1.tags[i1]]++
+			}
+			for i1 := range t2.tags {
+				if counts1[t2.tags[i1]] == 0 {
+					diffs = append(diffs, equal.Difference{Path: fmt.Sprintf("tags[%d]", i1), Value1: "", Value2: fmt.Sprint(t2.tags[i1]), Reason: equal.MissingElement})
+				} else {
+					counts1[t2.tags[i1]]--
+				}
+			}
+			for i1 := range t1.tags {
+				if counts1[t1.tags[i1]] > 0 {
+					diffs = append(diffs, equal.Difference{Path: fmt.Sprintf("tags[%d]", i1), Value1: fmt.Sprint(t1.tags[i1]), Value2: "", Reason: equal.MissingElement})
+					counts1[t1.tags[i1]]--
+				}
+			}
+		}
+	}
+	if len(t1.named) != len(t2.named) {
+		diffs = append(diffs, equal.Difference{Path: "named", Value1: fmt.Sprint(len(t1.named)), Value2: fmt.Sprint(len(t2.named)), Reason: equal.DifferentLengths})
+	} else {
+		{
+			counts1 := make(map[string]int, len(t1.named))
+			for i1 := range t1.named {
+				counts1[t1.named[i1]]++
+			}
+			for i1 := range t2.named {
+				if counts1[t2.named[i1]] == 0 {
+					diffs = append(diffs, equal.Difference{Path: fmt.Sprintf("named[%d]", i1), Value1: "", Value2: fmt.Sprint(t2.named[i1]), Reason: equal.MissingElement})
+				} else {
+					counts1[t2.named[i1]]--
+				}
+			}
+			for i1 := range t1.named {
+				if counts1[t1.named[i1]] > 0 {
+					diffs = append(diffs, equal.Difference{Path: fmt.Sprintf("named[%d]", i1), Value1: fmt.Sprint(t1.named[i1]), Value2: "", Reason: equal.MissingElement})
+					counts1[t1.named[i1]]--
+				}
+			}
+		}
+	}
+	if len(t1.ys) != len(t2.ys) {
+		diffs = append(diffs, equal.Difference{Path: "ys", Value1: fmt.Sprint(len(t1.ys)), Value2: fmt.Sprint(len(t2.ys)), Reason: equal.DifferentLengths})
+	} else {
+		{
+			eq1 := func(t1, t2 Y) bool {
+				if !EqualY((&t1), (&t2)) {
+					return false
+				}
+				return true
+			}
+			matched1 := make([]bool, len(t2.ys))
+			for i1 := range t1.ys {
+				found1 := false
+				for j1 := range t2.ys {
+					if !matched1[j1] && eq1(t1.ys[i1], t2.ys[j1]) {
+						matched1[j1] = true
+						found1 = true
+						break
+					}
+				}
+				if !found1 {
+					diffs = append(diffs, equal.Difference{Path: fmt.Sprintf("ys[%d]", i1), Value1: fmt.Sprint(t1.ys[i1]), Value2: "", Reason: equal.MissingElement})
+				}
+			}
+			for j1 := range t2.ys {
+				if !matched1[j1] {
+					diffs = append(diffs, equal.Difference{Path: fmt.Sprintf("ys[%d]", j1), Value1: "", Value2: fmt.Sprint(t2.ys[j1]), Reason: equal.MissingElement})
+				}
+			}
+		}
+	}
+	for key1, value11 := range t1.byKey {
+		if value12, ok := t2.byKey[key1]; !ok {
+			diffs = append(diffs, equal.Difference{Path: fmt.Sprintf("byKey[%#v]", key1), Value1: fmt.Sprint(value11), Value2: "", Reason: equal.MissingKey})
+		} else {
+			if len(value11) != len(value12) {
+				diffs = append(diffs, equal.Difference{Path: fmt.Sprintf("byKey[%#v]", key1), Value1: fmt.Sprint(len(value11)), Value2: fmt.Sprint(len(value12)), Reason: equal.DifferentLengths})
+			} else {
+				{
+					counts1 := make(map[int]int, len(value11))
+					for i1 := range value11 {
+						counts1[value11[i1]]++
+					}
+					for i1 := range value12 {
+						if counts1[value12[i1]] == 0 {
+							diffs = append(diffs, equal.Difference{Path: fmt.Sprintf("byKey[%#v][%d]", key1, i1), Value1: "", Value2: fmt.Sprint(value12[i1]), Reason: equal.MissingElement})
+						} else {
+							counts1[value12[i1]]--
+						}
+					}
+					for i1 := range value11 {
+						if counts1[value11[i1]] > 0 {
+							diffs = append(diffs, equal.Difference{Path: fmt.Sprintf("byKey[%#v][%d]", key1, i1), Value1: fmt.Sprint(value11[i1]), Value2: "", Reason: equal.MissingElement})
+							counts1[value11[i1]]--
+						}
+					}
+				}
+			}
+		}
+	}
+	for key1, value12 := range t2.byKey {
+		if _, ok := t1.byKey[key1]; !ok {
+			diffs = append(diffs, equal.Difference{Path: fmt.Sprintf("byKey[%#v]", key1), Value1: "", Value2: fmt.Sprint(value12), Reason: equal.MissingKey})
+		}
+	}
+	return diffs
+}
+`,
+}
+
+// hash of unordered slices
+var hashUnorderedIn = map[string]interface{}{
+	`test`: `package test
+
+type Y struct {
+	a []int
+}
+
+type Tags []string
+
+type X struct {
+	tags  []string         ` + "`" + `goequal:"unordered"` + "`" + `
+	named Tags             ` + "`" + `goequal:"unordered"` + "`" + `
+	ys    []Y              ` + "`" + `goequal:"unordered"` + "`" + `
+	byKey map[string][]int ` + "`" + `goequal:"unordered"` + "`" + `
+}
+`,
+}
+
+var hashUnorderedOut = map[Type]string{
+	{"Y", "test"}: `// Code generated by goequal for type: Y; DO NOT EDIT
+package test
+
+import "hash/maphash"
+
+func EqualY(t1, t2 *Y) bool {
+	if t1 == t2 {
+		return true
+	}
+	if t1 == nil || t2 == nil {
+		return false
+	}
+	if len(t1.a) != len(t2.a) {
+		return false
+	}
+	for i1 := range t1.a {
+		if t1.a[i1] != t2.a[i1] {
+			return false
+		}
+	}
+	return true
+}
+
+func HashY(t *Y, h *maphash.Hash) {
+	if t == nil {
+		h.WriteByte(0)
+		return
+	}
+	h.WriteByte(1)
+	maphash.WriteComparable(h, len(t.a))
+	for i1 := range t.a {
+		maphash.WriteComparable(h, t.a[i1])
+	}
+}
+`,
+	{"X", "test"}: `// Code generated by goequal for type: X; DO NOT EDIT
+package test
+
+import "hash/maphash"
+
+func EqualX(t1, t2 *X) bool {
+	if t1 == t2 {
+		return true
+	}
+	if t1 == nil || t2 == nil {
+		return false
+	}
+	if len(t1.tags) != len(t2.tags) {
+		return false
+	}
+	{
+		counts1 := make(map[string]int, len(t1.tags))
+		for i1 := range t1.tags {
+			counts1[t1.tags[i1]]++
+		}
+		for i1 := range t2.tags {
+			if counts1[t2.tags[i1]] == 0 {
+				return false
+			}
+			counts1[t2.tags[i1]]--
+		}
+	}
+	if len(t1.named) != len(t2.named) {
+		return false
+	}
+	{
+		counts1 := make(map[string]int, len(t1.named))
+		for i1 := range t1.named {
+			counts1[t1.named[i1]]++
+		}
+		for i1 := range t2.named {
+			if counts1[t2.named[i1]] == 0 {
+				return false
+			}
+			counts1[t2.named[i1]]--
+		}
+	}
+	if len(t1.ys) != len(t2.ys) {
+		return false
+	}
+	{
+		eq1 := func(t1, t2 Y) bool {
+			if !EqualY((&t1), (&t2)) {
+				return false
+			}
+			return true
+		}
+		matched1 := make([]bool, len(t2.ys))
+		for i1 := range t1.ys {
+			found1 := false
+			for j1 := range t2.ys {
+				if !matched1[j1] && eq1(t1.ys[i1], t2.ys[j1]) {
+					matched1[j1] = true
+					found1 = true
+					break
+				}
+			}
+			if !found1 {
+				return false
+			}
+		}
+	}
+	if len(t1.byKey) != len(t2.byKey) {
+		return false
+	}
+	for key1, value11 := range t1.byKey {
+		if value12, ok := t2.byKey[key1]; !ok {
+			return false
+		} else {
+			if len(value11) != len(value12) {
+				return false
+			}
+			{
+				counts1 := make(map[int]int, len(value11))
+				for i1 := range value11 {
+					counts1[value11[i1]]++
+				}
+				for i1 := range value12 {
+					if counts1[value12[i1]] == 0 {
+						return false
+					}
+					counts1[value12[i1]]--
+				}
+			}
+		}
+	}
+	return true
+}
+
+func HashX(t *X, h *maphash.Hash) {
+	if t == nil {
+		h.WriteByte(0)
+		return
+	}
+	h.WriteByte(1)
+	maphash.WriteComparable(h, len(t.tags))
+	{
+		var sum1 uint64
+		h1 := new(maphash.Hash)
+		h1.SetSeed(h.Seed())
+		for _, value11 := range t.tags {
+			h1.Reset()
+			maphash.WriteComparable(h1, value11)
+			sum1 += h1.Sum64()
+		}
+		maphash.WriteComparable(h, sum1)
+	}
+	maphash.WriteComparable(h, len(t.named))
+	{
+		var sum1 uint64
+		h1 := new(maphash.Hash)
+		h1.SetSeed(h.Seed())
+		for _, value11 := range t.named {
+			h1.Reset()
+			maphash.WriteComparable(h1, value11)
+			sum1 += h1.Sum64()
+		}
+		maphash.WriteComparable(h, sum1)
+	}
+	maphash.WriteComparable(h, len(t.ys))
+	{
+		var sum1 uint64
+		h1 := new(maphash.Hash)
+		h1.SetSeed(h.Seed())
+		for _, value11 := range t.ys {
+			h1.Reset()
+			HashY((&value11), h1)
+			sum1 += h1.Sum64()
+		}
+		maphash.WriteComparable(h, sum1)
+	}
+	{
+		var sum1 uint64
+		h1 := new(maphash.Hash)
+		h1.SetSeed(h.Seed())
+		for key1, value11 := range t.byKey {
+			h1.Reset()
+			maphash.WriteComparable(h1, key1)
+			maphash.WriteComparable(h1, len(value11))
+			{
+				var sum2 uint64
+				h2 := new(maphash.Hash)
+				h2.SetSeed(h1.Seed())
+				for _, value21 := range value11 {
+					h2.Reset()
+					maphash.WriteComparable(h2, value21)
+					sum2 += h2.Sum64()
+				}
+				maphash.WriteComparable(h1, sum2)
+			}
+			sum1 += h1.Sum64()
+		}
+		maphash.WriteComparable(h, sum1)
+	}
+}
+`,
+}
+
+// key tag
+var keyTagIn = map[string]interface{}{
+	`test`: `package test
+
+type LineItem struct {
+	ID  int
+	Qty int
+}
+
+type Order struct {
+	Items  []*LineItem            ` + "`" + `goequal:"key=ID"` + "`" + `
+	Lines  []LineItem             ` + "`" + `goequal:"key=ID"` + "`" + `
+	ByUser map[string][]*LineItem ` + "`" + `goequal:"key=ID"` + "`" + `
+}
+`,
+}
+
+var keyTagOut = map[Type]string{
+	{"LineItem", "test"}: `// Code generated by goequal for type: LineItem; DO NOT EDIT
+package test
+
+func EqualLineItem(t1, t2 *LineItem) bool {
+	if t1 == t2 {
+		return true
+	}
+	if t1 == nil || t2 == nil {
+		return false
+	}
+	if t1.ID != t2.ID {
+		return false
+	}
+	if t1.Qty != t2.Qty {
+		return false
+	}
+	return true
+}
+`,
+	{"Order", "test"}: `// Code generated by goequal for type: Order; DO NOT EDIT
+package test
+
+func EqualOrder(t1, t2 *Order) bool {
+	if t1 == t2 {
+		return true
+	}
+	if t1 == nil || t2 == nil {
+		return false
+	}
+	if len(t1.Items) != len(t2.Items) {
+		return false
+	}
+	{
+		byKey11 := make(map[int][]*LineItem, len(t1.Items))
+		for _, value11 := range t1.Items {
+			if value11 == nil {
+				continue
+			}
+			key1 := value11.ID
+			byKey11[key1] = append(byKey11[key1], value11)
+		}
+		byKey12 := make(map[int][]*LineItem, len(t2.Items))
+		for _, value12 := range t2.Items {
+			if value12 == nil {
+				continue
+			}
+			key1 := value12.ID
+			byKey12[key1] = append(byKey12[key1], value12)
+		}
+		if len(byKey11) != len(byKey12) {
+			return false
+		}
+		for key1, group11 := range byKey11 {
+			if group12, ok := byKey12[key1]; !ok {
+				return false
+			} else if len(group11) != len(group12) {
+				return false
+			} else {
+				for j1, value11 := range group11 {
+					value12 := group12[j1]
+					if !EqualLineItem(value11, value12) {
+						return false
+					}
+				}
+			}
+		}
+	}
+	if len(t1.Lines) != len(t2.Lines) {
+		return false
+	}
+	{
+		byKey11 := make(map[int][]LineItem, len(t1.Lines))
+		for _, value11 := range t1.Lines {
+			key1 := value11.ID
+			byKey11[key1] = append(byKey11[key1], value11)
+		}
+		byKey12 := make(map[int][]LineItem, len(t2.Lines))
+		for _, value12 := range t2.Lines {
+			key1 := value12.ID
+			byKey12[key1] = append(byKey12[key1], value12)
+		}
+		for key1, group11 := range byKey11 {
+			if group12, ok := byKey12[key1]; !ok {
+				return false
+			} else if len(group11) != len(group12) {
+				return false
+			} else {
+				for j1, value11 := range group11 {
+					value12 := group12[j1]
+					if !EqualLineItem((&value11), (&value12)) {
+						return false
+					}
+				}
+			}
+		}
+	}
+	if len(t1.ByUser) != len(t2.ByUser) {
+		return false
+	}
+	for key1, value11 := range t1.ByUser {
+		if value12, ok := t2.ByUser[key1]; !ok {
+			return false
+		} else {
+			if len(value11) != len(value12) {
+				return false
+			}
+			{
+				byKey21 := make(map[int][]*LineItem, len(value11))
+				for _, value21 := range value11 {
+					if value21 == nil {
+						continue
+					}
+					key2 := value21.ID
+					byKey21[key2] = append(byKey21[key2], value21)
+				}
+				byKey22 := make(map[int][]*LineItem, len(value12))
+				for _, value22 := range value12 {
+					if value22 == nil {
+						continue
+					}
+					key2 := value22.ID
+					byKey22[key2] = append(byKey22[key2], value22)
+				}
+				if len(byKey21) != len(byKey22) {
+					return false
+				}
+				for key2, group21 := range byKey21 {
+					if group22, ok := byKey22[key2]; !ok {
+						return false
+					} else if len(group21) != len(group22) {
+						return false
+					} else {
+						for j2, value21 := range group21 {
+							value22 := group22[j2]
+							if !EqualLineItem(value21, value22) {
+								return false
+							}
+						}
+					}
+				}
+			}
+		}
+	}
+	return true
+}
+`,
+}
+
+// diff of slices matched by key
+var diffKeyedIn = map[string]interface{}{
+	`test`: `package test
+
+type LineItem struct {
+	ID  int
+	Qty int
+}
+
+type Order struct {
+	Items []*LineItem ` + "`" + `goequal:"key=ID"` + "`" + `
+}
+`,
+}
+
+var diffKeyedOut = map[Type]string{
+	{"LineItem", "test"}: `// Code generated by goequal for type: LineItem; DO NOT EDIT
+package test
+
+import "fmt"
+import "github.com/gadumitrachioaiei/goequal/equal"
+
+func EqualLineItem(t1, t2 *LineItem) bool {
+	if t1 == t2 {
+		return true
+	}
+	if t1 == nil || t2 == nil {
+		return false
+	}
+	if t1.ID != t2.ID {
+		return false
+	}
+	if t1.Qty != t2.Qty {
+		return false
+	}
+	return true
+}
+
+func DiffLineItem(t1, t2 *LineItem) []equal.Difference {
+	if t1 == t2 {
+		return nil
+	}
+	if t1 == nil || t2 == nil {
+		return []equal.Difference{{Value1: fmt.Sprint(t1), Value2: fmt.Sprint(t2), Reason: equal.NilValue}}
+	}
+	var diffs []equal.Difference
+	if t1.ID != t2.ID {
+		diffs = append(diffs, equal.Difference{Path: "ID", Value1: fmt.Sprint(t1.ID), Value2: fmt.Sprint(t2.ID), Reason: equal.DifferentValues})
+	}
+	if t1.Qty != t2.Qty {
+		diffs = append(diffs, equal.Difference{Path: "Qty", Value1: fmt.Sprint(t1.Qty), Value2: fmt.Sprint(t2.Qty), Reason: equal.DifferentValues})
+	}
+	return diffs
+}
+`,
+	{"Order", "test"}: `// Code generated by goequal for type: Order; DO NOT EDIT
+package test
+
+import "fmt"
+import "github.com/gadumitrachioaiei/goequal/equal"
+
+func EqualOrder(t1, t2 *Order) bool {
+	if t1 == t2 {
+		return true
+	}
+	if t1 == nil || t2 == nil {
+		return false
+	}
+	if len(t1.Items) != len(t2.Items) {
+		return false
+	}
+	{
+		byKey11 := make(map[int][]*LineItem, len(t1.Items))
+		for _, value11 := range t1.Items {
+			if value11 == nil {
+				continue
+			}
+			key1 := value11.ID
+			byKey11[key1] = append(byKey11[key1], value11)
+		}
+		byKey12 := make(map[int][]*LineItem, len(t2.Items))
+		for _, value12 := range t2.Items {
+			if value12 == nil {
+				continue
+			}
+			key1 := value12.ID
+			byKey12[key1] = append(byKey12[key1], value12)
+		}
+		if len(byKey11) != len(byKey12) {
+			return false
+		}
+		for key1, group11 := range byKey11 {
+			if group12, ok := byKey12[key1]; !ok {
+				return false
+			} else if len(group11) != len(group12) {
+				return false
+			} else {
+				for j1, value11 := range group11 {
+					value12 := group12[j1]
+					if !EqualLineItem(value11, value12) {
+						return false
+					}
+				}
+			}
+		}
+	}
+	return true
+}
+
+func DiffOrder(t1, t2 *Order) []equal.Difference {
+	if t1 == t2 {
+		return nil
+	}
+	if t1 == nil || t2 == nil {
+		return []equal.Difference{{Value1: fmt.Sprint(t1), Value2: fmt.Sprint(t2), Reason: equal.NilValue}}
+	}
+	var diffs []equal.Difference
+	if len(t1.Items) != len(t2.Items) {
+		diffs = append(diffs, equal.Difference{Path: "Items", Value1: fmt.Sprint(len(t1.Items)), Value2: fmt.Sprint(len(t2.Items)), Reason: equal.DifferentLengths})
+	} else {
+		{
+			byKey11 := make(map[int][]*LineItem, len(t1.Items))
+			for _, value11 := range t1.Items {
+				if value11 == nil {
+					continue
+				}
+				key1 := value11.ID
+				byKey11[key1] = append(byKey11[key1], value11)
+			}
+			byKey12 := make(map[int][]*LineItem, len(t2.Items))
+			for _, value12 := range t2.Items {
+				if value12 == nil {
+					continue
+				}
+				key1 := value12.ID
+				byKey12[key1] = append(byKey12[key1], value12)
+			}
+			for key1, group11 := range byKey11 {
+				if group12, ok := byKey12[key1]; !ok {
+					for _, value11 := range group11 {
+						diffs = append(diffs, equal.Difference{Path: fmt.Sprintf("Items[%#v]", key1), Value1: fmt.Sprint(value11), Value2: "", Reason: equal.MissingKey})
+					}
+				} else if len(group11) != len(group12) {
+					diffs = append(diffs, equal.Difference{Path: fmt.Sprintf("Items[%#v]", key1), Value1: fmt.Sprint(group11), Value2: fmt.Sprint(group12), Reason: equal.DuplicateKey})
+				} else {
+					for j1, value11 := range group11 {
+						value12 := group12[j1]
+						diffs = equal.Nested(diffs, fmt.Sprintf("Items[%#v]", key1), DiffLineItem(value11, value12))
+					}
+				}
+			}
+			for key1, group12 := range byKey12 {
+				if _, ok := byKey11[key1]; !ok {
+					for _, value12 := range group12 {
+						diffs = append(diffs, equal.Difference{Path: fmt.Sprintf("Items[%#v]", key1), Value1: "", Value2: fmt.Sprint(value12), Reason: equal.MissingKey})
+					}
+				}
+			}
+		}
+	}
+	return diffs
+}
+`,
+}
+
+// a linked list is walked by a loop, the differences of its values have paths relative to the first value
+var diffChainIn = map[string]interface{}{
+	`test`: `package test
+
+type Node struct {
+	Value    string
+	Next     *Node
+	Children []*Node
+}
+`,
+}
+
+var diffChainOut = map[Type]string{
+	{"Node", "test"}: `// Code generated by goequal for type: Node; DO NOT EDIT
+package test
+
+import "fmt"
+import "github.com/gadumitrachioaiei/goequal/equal"
+
+func EqualNode(t1, t2 *Node) bool {
+	for {
+		if t1 == t2 {
+			return true
+		}
+		if t1 == nil || t2 == nil {
+			return false
+		}
+		if t1.Value != t2.Value {
+			return false
+		}
+		if len(t1.Children) != len(t2.Children) {
+			return false
+		}
+		for i1 := range t1.Children {
+			if !EqualNode(t1.Children[i1], t2.Children[i1]) {
+				return false
+			}
+		}
+		t1, t2 = t1.Next, t2.Next
+	}
+}
+
+func DiffNode(t1, t2 *Node) []equal.Difference {
+	var chain []equal.Difference
+	for depth := 0; ; depth++ {
+		if t1 == t2 {
+			return chain
+		}
+		if t1 == nil || t2 == nil {
+			return equal.Nested(chain, equal.ChainPath("Next", depth), []equal.Difference{{Value1: fmt.Sprint(t1), Value2: fmt.Sprint(t2), Reason: equal.NilValue}})
+		}
+		var diffs []equal.Difference
+		if t1.Value != t2.Value {
+			diffs = append(diffs, equal.Difference{Path: "Value", Value1: fmt.Sprint(t1.Value), Value2: fmt.Sprint(t2.Value), Reason: equal.DifferentValues})
+		}
+		if len(t1.Children) != len(t2.Children) {
+			diffs = append(diffs, equal.Difference{Path: "Children", Value1: fmt.Sprint(len(t1.Children)), Value2: fmt.Sprint(len(t2.Children)), Reason: equal.DifferentLengths})
+		} else {
+			for i1 := range t1.Children {
+				diffs = equal.Nested(diffs, fmt.Sprintf("Children[%d]", i1), DiffNode(t1.Children[i1], t2.Children[i1]))
+			}
+		}
+		if len(diffs) > 0 {
+			chain = equal.Nested(chain, equal.ChainPath("Next", depth), diffs)
+		}
+		t1, t2 = t1.Next, t2.Next
+	}
+}
+`,
+}
+
+// tolerance tags
+var toleranceTagIn = map[string]interface{}{
+	`test`: `package test
+
+type Celsius float64
+
+type Readings []float32
+
+type X struct {
+	a float64            ` + "`" + `goequal:"abs=1e-9"` + "`" + `
+	b Celsius            ` + "`" + `goequal:"rel=1e-6"` + "`" + `
+	c [3]float32         ` + "`" + `goequal:"ulps=4"` + "`" + `
+	d complex128         ` + "`" + `goequal:"abs=1e-9,rel=1e-6"` + "`" + `
+	e map[string]float64 ` + "`" + `goequal:"abs=0.5"` + "`" + `
+	f Readings           ` + "`" + `goequal:"ulps=2"` + "`" + `
+	h float64
+}
+`,
+}
+
+var toleranceTagOut = map[Type]string{
+	{"X", "test"}: `// Code generated by goequal for type: X; DO NOT EDIT
+package test
+
+import "github.com/gadumitrachioaiei/goequal/equal"
+
+func EqualX(t1, t2 *X) bool {
+	if t1 == t2 {
+		return true
+	}
+	if t1 == nil || t2 == nil {
+		return false
+	}
+	if t1.a != t2.a && !equal.AbsEqual(t1.a, t2.a, 1e-9) {
+		return false
+	}
+	if t1.b != t2.b && !equal.RelEqual(t1.b, t2.b, 1e-6) {
+		return false
+	}
+	for i1 := range t1.c {
+		if t1.c[i1] != t2.c[i1] && !equal.ULPsEqual(t1.c[i1], t2.c[i1], 4) {
+			return false
+		}
+	}
+	if (real(t1.d) != real(t2.d) && !equal.AbsEqual(real(t1.d), real(t2.d), 1e-9) && !equal.RelEqual(real(t1.d), real(t2.d), 1e-6)) || (imag(t1.d) != imag(t2.d) && !equal.AbsEqual(imag(t1.d), imag(t2.d), 1e-9) && !equal.RelEqual(imag(t1.d), imag(t2.d), 1e-6)) {
+		return false
+	}
+	if len(t1.e) != len(t2.e) {
+		return false
+	}
+	for key1, value11 := range t1.e {
+		if value12, ok := t2.e[key1]; !ok {
+			return false
+		} else {
+			if value11 != value12 && !equal.AbsEqual(value11, value12, 0.5) {
+				return false
+			}
+		}
+	}
+	if len(t1.f) != len(t2.f) {
+		return false
+	}
+	for i1 := range t1.f {
+		if t1.f[i1] != t2.f[i1] && !equal.ULPsEqual(t1.f[i1], t2.f[i1], 2) {
+			return false
+		}
+	}
+	if t1.h != t2.h {
+		return false
+	}
+	return true
+}
+`,
+}
+
+// NaN values equal to each other
+var nanEqualIn = map[string]interface{}{
+	`test`: `package test
+
+type Celsius float64
+
+type X struct {
+	a float64
+	b Celsius
+	c [3]float32
+	d complex64
+	e []float64 ` + "`" + `goequal:"unordered"` + "`" + `
+	f [2]int
+}
+`,
+}
+
+var nanEqualOut = map[Type]string{
+	{"Celsius", "test"}: `// Code generated by goequal for type: Celsius; DO NOT EDIT
+package test
+
+import "github.com/gadumitrachioaiei/goequal/equal"
+import "hash/maphash"
+
+func EqualCelsius(t1, t2 Celsius) bool {
+	if t1 != t2 && !equal.BothNaN(t1, t2) {
+		return false
+	}
+	return true
+}
+
+func HashCelsius(t Celsius, h *maphash.Hash) {
+	equal.HashFloat(h, t)
+}
+`,
+	{"X", "test"}: `// Code generated by goequal for type: X; DO NOT EDIT
+package test
+
+import "github.com/gadumitrachioaiei/goequal/equal"
+import "hash/maphash"
+
+func EqualX(t1, t2 *X) bool {
+	if t1 == t2 {
+		return true
+	}
+	if t1 == nil || t2 == nil {
+		return false
+	}
+	if t1.a != t2.a && !equal.BothNaN(t1.a, t2.a) {
+		return false
+	}
+	if !EqualCelsius(t1.b, t2.b) {
+		return false
+	}
+	for i1 := range t1.c {
+		if t1.c[i1] != t2.c[i1] && !equal.BothNaN(t1.c[i1], t2.c[i1]) {
+			return false
+		}
+	}
+	if (real(t1.d) != real(t2.d) && !equal.BothNaN(real(t1.d), real(t2.d))) || (imag(t1.d) != imag(t2.d) && !equal.BothNaN(imag(t1.d), imag(t2.d))) {
+		return false
+	}
+	if len(t1.e) != len(t2.e) {
+		return false
+	}
+	{
+		eq1 := func(t1, t2 float64) bool {
+			if t1 != t2 && !equal.BothNaN(t1, t2) {
+				return false
+			}
+			return true
+		}
+		matched1 := make([]bool, len(t2.e))
+		for i1 := range t1.e {
+			found1 := false
+			for j1 := range t2.e {
+				if !matched1[j1] && eq1(t1.e[i1], t2.e[j1]) {
+					matched1[j1] = true
+					found1 = true
+					break
+				}
+			}
+			if !found1 {
+				return false
+			}
+		}
+	}
+	if t1.f != t2.f {
+		return false
+	}
+	return true
+}
+
+func HashX(t *X, h *maphash.Hash) {
+	if t == nil {
+		h.WriteByte(0)
+		return
+	}
+	h.WriteByte(1)
+	equal.HashFloat(h, t.a)
+	HashCelsius(t.b, h)
+	for i1 := range t.c {
+		equal.HashFloat(h, t.c[i1])
+	}
+	equal.HashFloat(h, real(t.d))
+	equal.HashFloat(h, imag(t.d))
+	maphash.WriteComparable(h, len(t.e))
+	{
+		var sum1 uint64
+		h1 := new(maphash.Hash)
+		h1.SetSeed(h.Seed())
+		for _, value11 := range t.e {
+			h1.Reset()
+			equal.HashFloat(h1, value11)
+			sum1 += h1.Sum64()
+		}
+		maphash.WriteComparable(h, sum1)
+	}
+	maphash.WriteComparable(h, t.f)
+}
+`,
+}
+
+// semantic tag
+var semanticTagIn = map[string]interface{}{
+	`test`: `package test
+
+type Y struct {
+	a int
+	q *int
+}
+
+type Tags []string
+
+type X struct {
+	p     *int              ` + "`" + `goequal:"semantic"` + "`" + `
+	y     *Y                ` + "`" + `goequal:"semantic"` + "`" + `
+	ys    []*Y              ` + "`" + `goequal:"semantic"` + "`" + `
+	tags  *Tags             ` + "`" + `goequal:"semantic"` + "`" + `
+	m     map[string]*[]int ` + "`" + `goequal:"semantic"` + "`" + `
+	plain *int
+}
+`,
+}
+
+var semanticTagOut = map[Type]string{
+	{"Y", "test"}: `// Code generated by goequal for type: Y; DO NOT EDIT
+package test
+
+func EqualY(t1, t2 *Y) bool {
+	if t1 == t2 {
+		return true
+	}
+	if t1 == nil || t2 == nil {
+		return false
+	}
+	if t1.a != t2.a {
+		return false
+	}
+	if t1.q != t2.q {
+		if t1.q == nil || t2.q == nil {
+			return false
+		}
+		if (*t1.q) != (*t2.q) {
+			return false
+		}
+	}
+	return true
+}
+`,
+	{"X", "test"}: `// Code generated by goequal for type: X; DO NOT EDIT
+package test
+
+import "github.com/gadumitrachioaiei/goequal/equal"
+
+func EqualX(t1, t2 *X) bool {
+	if t1 == t2 {
+		return true
+	}
+	if t1 == nil || t2 == nil {
+		return false
+	}
+	if t1.p != t2.p {
+		if (*equal.OrZero(t1.p)) != (*equal.OrZero(t2.p)) {
+			return false
+		}
+	}
+	if !EqualY(equal.OrZero(t1.y), equal.OrZero(t2.y)) {
+		return false
+	}
+	if len(t1.ys) != len(t2.ys) {
+		return false
+	}
+	for i1 := range t1.ys {
+		if !EqualY(equal.OrZero(t1.ys[i1]), equal.OrZero(t2.ys[i1])) {
+			return false
+		}
+	}
+	if t1.tags != t2.tags {
+		if len((*equal.OrZero(t1.tags))) != len((*equal.OrZero(t2.tags))) {
+			return false
+		}
+		for i1 := range *equal.OrZero(t1.tags) {
+			if (*equal.OrZero(t1.tags))[i1] != (*equal.OrZero(t2.tags))[i1] {
+				return false
+			}
+		}
+	}
+	if len(t1.m) != len(t2.m) {
+		return false
+	}
+	for key1, value11 := range t1.m {
+		if value12, ok := t2.m[key1]; !ok {
+			return false
+		} else {
+			if value11 != value12 {
+				if len((*equal.OrZero(value11))) != len((*equal.OrZero(value12))) {
+					return false
+				}
+				for i1 := range *equal.OrZero(value11) {
+					if (*equal.OrZero(value11))[i1] != (*equal.OrZero(value12))[i1] {
+						return false
+					}
+				}
+			}
+		}
+	}
+	if t1.plain != t2.plain {
+		if t1.plain == nil || t2.plain == nil {
+			return false
+		}
+		if (*t1.plain) != (*t2.plain) {
+			return false
+		}
+	}
+	return true
+}
+`,
+}
+
+// nil pointers equal to pointers to zero values
+var semanticIn = map[string]interface{}{
+	`test`: `package test
+
+type Y struct {
+	a int
+	q *int
+}
+
+type Item struct {
+	ID int
+}
+
+type X struct {
+	y     *Y
+	ys    []*Y
+	items []*Item ` + "`" + `goequal:"key=ID"` + "`" + `
+}
+`,
+}
+
+var semanticOut = map[Type]string{
+	{"Y", "test"}: `// Code generated by goequal for type: Y; DO NOT EDIT
+package test
+
+import "github.com/gadumitrachioaiei/goequal/equal"
+import "hash/maphash"
+
+func EqualY(t1, t2 *Y) bool {
+	if t1 == t2 {
+		return true
+	}
+	if t1 == nil {
+		t1 = new(Y)
+	}
+	if t2 == nil {
+		t2 = new(Y)
+	}
+	if t1.a != t2.a {
+		return false
+	}
+	if t1.q != t2.q {
+		if (*equal.OrZero(t1.q)) != (*equal.OrZero(t2.q)) {
+			return false
+		}
+	}
+	return true
+}
+
+func HashY(t *Y, h *maphash.Hash) {
+	if t == nil {
+		t = new(Y)
+	}
+	maphash.WriteComparable(h, t.a)
+	maphash.WriteComparable(h, (*equal.OrZero(t.q)))
+}
+`,
+	{"Item", "test"}: `// Code generated by goequal for type: Item; DO NOT EDIT
+package test
+
+import "hash/maphash"
+
+func EqualItem(t1, t2 *Item) bool {
+	if t1 == t2 {
+		return true
+	}
+	if t1 == nil {
+		t1 = new(Item)
+	}
+	if t2 == nil {
+		t2 = new(Item)
+	}
+	if t1.ID != t2.ID {
+		return false
+	}
+	return true
+}
+
+func HashItem(t *Item, h *maphash.Hash) {
+	if t == nil {
+		t = new(Item)
+	}
+	maphash.WriteComparable(h, t.ID)
+}
+`,
+	{"X", "test"}: `// Code generated by goequal for type: X; DO NOT EDIT
+package test
+
+import "github.com/gadumitrachioaiei/goequal/equal"
+import "hash/maphash"
+
+func EqualX(t1, t2 *X) bool {
+	if t1 == t2 {
+		return true
+	}
+	if t1 == nil {
+		t1 = new(X)
+	}
+	if t2 == nil {
+		t2 = new(X)
+	}
+	if !EqualY(equal.OrZero(t1.y), equal.OrZero(t2.y)) {
+		return false
+	}
+	if len(t1.ys) != len(t2.ys) {
+		return false
+	}
+	for i1 := range t1.ys {
+		if !EqualY(equal.OrZero(t1.ys[i1]), equal.OrZero(t2.ys[i1])) {
+			return false
+		}
+	}
+	if len(t1.items) != len(t2.items) {
+		return false
+	}
+	{
+		byKey11 := make(map[int][]*Item, len(t1.items))
+		for _, value11 := range t1.items {
+			if value11 == nil {
+				value11 = new(Item)
+			}
+			key1 := value11.ID
+			byKey11[key1] = append(byKey11[key1], value11)
+		}
+		byKey12 := make(map[int][]*Item, len(t2.items))
+		for _, value12 := range t2.items {
+			if value12 == nil {
+				value12 = new(Item)
+			}
+			key1 := value12.ID
+			byKey12[key1] = append(byKey12[key1], value12)
+		}
+		for key1, group11 := range byKey11 {
+			if group12, ok := byKey12[key1]; !ok {
+				return false
+			} else if len(group11) != len(group12) {
+				return false
+			} else {
+				for j1, value11 := range group11 {
+					value12 := group12[j1]
+					if !EqualItem(equal.OrZero(value11), equal.OrZero(value12)) {
+						return false
+					}
+				}
+			}
+		}
+	}
+	return true
+}
+
+func HashX(t *X, h *maphash.Hash) {
+	if t == nil {
+		t = new(X)
+	}
+	HashY(equal.OrZero(t.y), h)
+	maphash.WriteComparable(h, len(t.ys))
+	for i1 := range t.ys {
+		HashY(equal.OrZero(t.ys[i1]), h)
+	}
+	maphash.WriteComparable(h, len(t.items))
+	{
+		var sum1 uint64
+		h1 := new(maphash.Hash)
+		h1.SetSeed(h.Seed())
+		for _, value11 := range t.items {
+			h1.Reset()
+			HashItem(equal.OrZero(value11), h1)
+			sum1 += h1.Sum64()
+		}
+		maphash.WriteComparable(h, sum1)
+	}
+}
+`,
+}
+
+// types with Equal methods are compared by them
+var equalMethodIn = map[string]interface{}{
+	`test`: `package test
+
+import "time"
+
+type Money struct {
+	cents int64
+	cur   string
+}
+
+func (m Money) Equal(o Money) bool { return m.cents == o.cents }
+
+type Level int
+
+func (l *Level) Equal(o *Level) bool { return *l%10 == *o%10 }
+
+type X struct {
+	at     time.Time
+	atp    *time.Time
+	byDay  map[string]time.Time
+	price  Money
+	prices []*Money
+	levels []Level ` + "`" + `goequal:"unordered"` + "`" + `
+	lvl    Level
+}
+`,
+}
+
+var equalMethodOut = map[Type]string{
+	{"X", "test"}: `// Code generated by goequal for type: X; DO NOT EDIT
+package test
+
+func EqualX(t1, t2 *X) bool {
+	if t1 == t2 {
+		return true
+	}
+	if t1 == nil || t2 == nil {
+		return false
+	}
+	if !t1.at.Equal(t2.at) {
+		return false
+	}
+	if t1.atp != t2.atp {
+		if t1.atp == nil || t2.atp == nil {
+			return false
+		}
+		if !(*t1.atp).Equal((*t2.atp)) {
+			return false
+		}
+	}
+	if len(t1.byDay) != len(t2.byDay) {
+		return false
+	}
+	for key1, value11 := range t1.byDay {
+		if value12, ok := t2.byDay[key1]; !ok {
+			return false
+		} else {
+			if !value11.Equal(value12) {
+				return false
+			}
+		}
+	}
+	if !t1.price.Equal(t2.price) {
+		return false
+	}
+	if len(t1.prices) != len(t2.prices) {
+		return false
+	}
+	for i1 := range t1.prices {
+		if t1.prices[i1] != t2.prices[i1] {
+			if t1.prices[i1] == nil || t2.prices[i1] == nil {
+				return false
+			}
+			if !(*t1.prices[i1]).Equal((*t2.prices[i1])) {
+				return false
+			}
+		}
+	}
+	if len(t1.levels) != len(t2.levels) {
+		return false
+	}
+	{
+		eq1 := func(t1, t2 Level) bool {
+			if !t1.Equal(&t2) {
+				return false
+			}
+			return true
+		}
+		matched1 := make([]bool, len(t2.levels))
+		for i1 := range t1.levels {
+			found1 := false
+			for j1 := range t2.levels {
+				if !matched1[j1] && eq1(t1.levels[i1], t2.levels[j1]) {
+					matched1[j1] = true
+					found1 = true
+					break
+				}
+			}
+			if !found1 {
+				return false
+			}
+		}
+	}
+	if !t1.lvl.Equal(&t2.lvl) {
+		return false
+	}
+	return true
+}
+`,
+}
+
+// types with Equal methods are not hashed
+var hashEqualMethodIn = map[string]interface{}{
+	`test`: `package test
+
+import "time"
+
+type Money struct {
+	cents int64
+	cur   string
+}
+
+func (m Money) Equal(o Money) bool { return m.cents == o.cents }
+
+type Level int
+
+func (l *Level) Equal(o *Level) bool { return *l%10 == *o%10 }
+
+type X struct {
+	at     time.Time
+	atp    *time.Time
+	byDay  map[string]time.Time
+	price  Money
+	prices []*Money
+	levels []Level ` + "`" + `goequal:"unordered"` + "`" + `
+	lvl    Level
+}
+`,
+}
+
+var hashEqualMethodOut = map[Type]string{
+	{"X", "test"}: `// Code generated by goequal for type: X; DO NOT EDIT
+package test
+
+import "hash/maphash"
+
+func EqualX(t1, t2 *X) bool {
+	if t1 == t2 {
+		return true
+	}
+	if t1 == nil || t2 == nil {
+		return false
+	}
+	if !t1.at.Equal(t2.at) {
+		return false
+	}
+	if t1.atp != t2.atp {
+		if t1.atp == nil || t2.atp == nil {
+			return false
+		}
+		if !(*t1.atp).Equal((*t2.atp)) {
+			return false
+		}
+	}
+	if len(t1.byDay) != len(t2.byDay) {
+		return false
+	}
+	for key1, value11 := range t1.byDay {
+		if value12, ok := t2.byDay[key1]; !ok {
+			return false
+		} else {
+			if !value11.Equal(value12) {
+				return false
+			}
+		}
+	}
+	if !t1.price.Equal(t2.price) {
+		return false
+	}
+	if len(t1.prices) != len(t2.prices) {
+		return false
+	}
+	for i1 := range t1.prices {
+		if t1.prices[i1] != t2.prices[i1] {
+			if t1.prices[i1] == nil || t2.prices[i1] == nil {
+				return false
+			}
+			if !(*t1.prices[i1]).Equal((*t2.prices[i1])) {
+				return false
+			}
+		}
+	}
+	if len(t1.levels) != len(t2.levels) {
+		return false
+	}
+	{
+		eq1 := func(t1, t2 Level) bool {
+			if !t1.Equal(&t2) {
+				return false
+			}
+			return true
+		}
+		matched1 := make([]bool, len(t2.levels))
+		for i1 := range t1.levels {
+			found1 := false
+			for j1 := range t2.levels {
+				if !matched1[j1] && eq1(t1.levels[i1], t2.levels[j1]) {
+					matched1[j1] = true
+					found1 = true
+					break
+				}
+			}
+			if !found1 {
+				return false
+			}
+		}
+	}
+	if !t1.lvl.Equal(&t2.lvl) {
+		return false
+	}
+	return true
+}
+
+func HashX(t *X, h *maphash.Hash) {
+	if t == nil {
+		h.WriteByte(0)
+		return
+	}
+	h.WriteByte(1)
+	if t.atp == nil {
+		h.WriteByte(0)
+	} else {
+		h.WriteByte(1)
+	}
+	{
+		var sum1 uint64
+		h1 := new(maphash.Hash)
+		h1.SetSeed(h.Seed())
+		for key1 := range t.byDay {
+			h1.Reset()
+			maphash.WriteComparable(h1, key1)
+			sum1 += h1.Sum64()
+		}
+		maphash.WriteComparable(h, sum1)
+	}
+	maphash.WriteComparable(h, len(t.prices))
+	for i1 := range t.prices {
+		if t.prices[i1] == nil {
+			h.WriteByte(0)
+		} else {
+			h.WriteByte(1)
+		}
+	}
+	maphash.WriteComparable(h, len(t.levels))
+}
+`,
+}
+
+// named types from standard library are compared as the catalog says
+var stdlibIn = map[string]interface{}{
+	`test`: `package test
+
+import (
+	"container/list"
+	"database/sql"
+	"encoding/json"
+	"math/big"
+	"net/url"
+	"regexp"
+	"sync"
+	"time"
+)
+
+type X struct {
+	amount  big.Int
+	rates   []*big.Rat
+	link    *url.URL
+	name    sql.NullString
+	pattern *regexp.Regexp
+	raw     json.RawMessage
+	queue   list.List
+	cache   sync.Map
+	timeout time.Duration
+	mu      sync.Mutex
+}
+`,
+}
+
+var stdlibOut = map[Type]string{
+	{"X", "test"}: `// Code generated by goequal for type: X; DO NOT EDIT
+package test
+
+import "bytes"
+import "github.com/gadumitrachioaiei/goequal/equal"
+
+func EqualX(t1, t2 *X) bool {
+	if t1 == t2 {
+		return true
+	}
+	if t1 == nil || t2 == nil {
+		return false
+	}
+	if t1.amount.Cmp(&t2.amount) != 0 {
+		return false
+	}
+	if len(t1.rates) != len(t2.rates) {
+		return false
+	}
+	for i1 := range t1.rates {
+		if t1.rates[i1] != t2.rates[i1] {
+			if t1.rates[i1] == nil || t2.rates[i1] == nil {
+				return false
+			}
+			if (*t1.rates[i1]).Cmp(&(*t2.rates[i1])) != 0 {
+				return false
+			}
+		}
+	}
+	if t1.link != t2.link {
+		if t1.link == nil || t2.link == nil {
+			return false
+		}
+		if (*t1.link).String() != (*t2.link).String() {
+			return false
+		}
+	}
+	if t1.name.Valid != t2.name.Valid || t1.name.Valid && t1.name.String != t2.name.String {
+		return false
+	}
+	if t1.pattern != t2.pattern {
+		if t1.pattern == nil || t2.pattern == nil {
+			return false
+		}
+		if (*t1.pattern).String() != (*t2.pattern).String() {
+			return false
+		}
+	}
+	if !bytes.Equal(t1.raw, t2.raw) {
+		return false
+	}
+	if !equal.ListEqual(&t1.queue, &t2.queue) {
+		return false
+	}
+	if !equal.SyncMapEqual(&t1.cache, &t2.cache) {
+		return false
+	}
+	if t1.timeout != t2.timeout {
+		return false
+	}
+	return true
+}
+`,
+}
+
+// named types from standard library are hashed as the catalog says
+var hashStdlibIn = map[string]interface{}{
+	`test`: `package test
+
+import (
+	"container/list"
+	"database/sql"
+	"encoding/json"
+	"math/big"
+	"net/url"
+	"regexp"
+	"sync"
+	"time"
+)
+
+type X struct {
+	amount  big.Int
+	rates   []*big.Rat
+	link    *url.URL
+	name    sql.NullString
+	pattern *regexp.Regexp
+	raw     json.RawMessage
+	queue   list.List
+	cache   sync.Map
+	timeout time.Duration
+	mu      sync.Mutex
+}
+`,
+}
+
+var hashStdlibOut = map[Type]string{
+	{"X", "test"}: `// Code generated by goequal for type: X; DO NOT EDIT
+package test
+
+import "bytes"
+import "github.com/gadumitrachioaiei/goequal/equal"
+import "hash/maphash"
+
+func EqualX(t1, t2 *X) bool {
+	if t1 == t2 {
+		return true
+	}
+	if t1 == nil || t2 == nil {
+		return false
+	}
+	if t1.amount.Cmp(&t2.amount) != 0 {
+		return false
+	}
+	if len(t1.rates) != len(t2.rates) {
+		return false
+	}
+	for i1 := range t1.rates {
+		if t1.rates[i1] != t2.rates[i1] {
+			if t1.rates[i1] == nil || t2.rates[i1] == nil {
+				return false
+			}
+			if (*t1.rates[i1]).Cmp(&(*t2.rates[i1])) != 0 {
+				return false
+			}
+		}
+	}
+	if t1.link != t2.link {
+		if t1.link == nil || t2.link == nil {
+			return false
+		}
+		if (*t1.link).String() != (*t2.link).String() {
+			return false
+		}
+	}
+	if t1.name.Valid != t2.name.Valid || t1.name.Valid && t1.name.String != t2.name.String {
+		return false
+	}
+	if t1.pattern != t2.pattern {
+		if t1.pattern == nil || t2.pattern == nil {
+			return false
+		}
+		if (*t1.pattern).String() != (*t2.pattern).String() {
+			return false
+		}
+	}
+	if !bytes.Equal(t1.raw, t2.raw) {
+		return false
+	}
+	if !equal.ListEqual(&t1.queue, &t2.queue) {
+		return false
+	}
+	if !equal.SyncMapEqual(&t1.cache, &t2.cache) {
+		return false
+	}
+	if t1.timeout != t2.timeout {
+		return false
+	}
+	return true
+}
+
+func HashX(t *X, h *maphash.Hash) {
+	if t == nil {
+		h.WriteByte(0)
+		return
+	}
+	h.WriteByte(1)
+	maphash.WriteComparable(h, t.amount.String())
+	maphash.WriteComparable(h, len(t.rates))
+	for i1 := range t.rates {
+		if t.rates[i1] == nil {
+			h.WriteByte(0)
+		} else {
+			h.WriteByte(1)
+			maphash.WriteComparable(h, (*t.rates[i1]).String())
+		}
+	}
+	if t.link == nil {
+		h.WriteByte(0)
+	} else {
+		h.WriteByte(1)
+		maphash.WriteComparable(h, (*t.link).String())
+	}
+	maphash.WriteComparable(h, t.name.Valid)
+	if t.name.Valid {
+		maphash.WriteComparable(h, t.name.String)
+	}
+	if t.pattern == nil {
+		h.WriteByte(0)
+	} else {
+		h.WriteByte(1)
+		maphash.WriteComparable(h, (*t.pattern).String())
+	}
+	h.Write(t.raw)
+	maphash.WriteComparable(h, t.timeout)
+}
+`,
+}
+
+// named types from standard library are copied as the catalog says
+var cloneStdlibIn = map[string]interface{}{
+	`test`: `package test
+
+import (
+	"container/list"
+	"database/sql"
+	"encoding/json"
+	"math/big"
+	"net/url"
+	"regexp"
+	"sync"
+	"time"
+)
+
+type X struct {
+	amount  big.Int
+	rates   []*big.Rat
+	link    *url.URL
+	name    sql.NullString
+	pattern *regexp.Regexp
+	raw     json.RawMessage
+	queue   list.List
+	cache   sync.Map
+	timeout time.Duration
+	mu      sync.Mutex
+}
+`,
+}
+
+var cloneStdlibOut = map[Type]string{
+	{"X", "test"}: `// Code generated by goequal for type: X; DO NOT EDIT
+package test
+
+import "bytes"
+import "github.com/gadumitrachioaiei/goequal/equal"
+import "math/big"
+import "net/url"
+import "regexp"
+
+func EqualX(t1, t2 *X) bool {
+	if t1 == t2 {
+		return true
+	}
+	if t1 == nil || t2 == nil {
+		return false
+	}
+	if t1.amount.Cmp(&t2.amount) != 0 {
+		return false
+	}
+	if len(t1.rates) != len(t2.rates) {
+		return false
+	}
+	for i1 := range t1.rates {
+		if t1.rates[i1] != t2.rates[i1] {
+			if t1.rates[i1] == nil || t2.rates[i1] == nil {
+				return false
+			}
+			if (*t1.rates[i1]).Cmp(&(*t2.rates[i1])) != 0 {
+				return false
+			}
+		}
+	}
+	if t1.link != t2.link {
+		if t1.link == nil || t2.link == nil {
+			return false
+		}
+		if (*t1.link).String() != (*t2.link).String() {
+			return false
+		}
+	}
+	if t1.name.Valid != t2.name.Valid || t1.name.Valid && t1.name.String != t2.name.String {
+		return false
+	}
+	if t1.pattern != t2.pattern {
+		if t1.pattern == nil || t2.pattern == nil {
+			return false
+		}
+		if (*t1.pattern).String() != (*t2.pattern).String() {
+			return false
+		}
+	}
+	if !bytes.Equal(t1.raw, t2.raw) {
+		return false
+	}
+	if !equal.ListEqual(&t1.queue, &t2.queue) {
+		return false
+	}
+	if !equal.SyncMapEqual(&t1.cache, &t2.cache) {
+		return false
+	}
+	if t1.timeout != t2.timeout {
+		return false
+	}
+	return true
+}
+
+func CloneX(src *X) *X {
+	if src == nil {
+		return nil
+	}
+	dst := new(X)
+	dst.amount.Set(&src.amount)
+	if src.rates != nil {
+		dst.rates = make([]*big.Rat, len(src.rates))
+		for i1 := range src.rates {
+			if src.rates[i1] != nil {
+				dst.rates[i1] = new(big.Rat)
+				(*dst.rates[i1]).Set(&(*src.rates[i1]))
+			}
+		}
+	}
+	if src.link != nil {
+		dst.link = new(url.URL)
+		(*dst.link) = (*src.link)
+	}
+	dst.name = src.name
+	if src.pattern != nil {
+		dst.pattern = new(regexp.Regexp)
+		(*dst.pattern) = (*src.pattern)
+	}
+	dst.raw = append(src.raw[:0:0], src.raw...)
+	equal.CloneList(&dst.queue, &src.queue)
+	equal.CloneSyncMap(&dst.cache, &src.cache)
+	dst.timeout = src.timeout
+	return dst
+}
+`,
+}
+
+// interface values are compared with the functions of their dynamic types
+var dispatchIn = map[string]interface{}{
+	`test`: `package test
+
+type Shape interface {
+	Area() float64
+}
+
+type Circle struct {
+	r float64
+}
+
+func (c *Circle) Area() float64 { return 3 * c.r * c.r }
+
+type Square struct {
+	side float64
+}
+
+func (s Square) Area() float64 { return s.side * s.side }
+
+type Drawing struct {
+	main   Shape
+	shapes []Shape
+	meta   interface{}
+}
+`,
+}
+
+// dispatchStdlibIn has fields of interfaces of standard library, one of them implemented in the package
+var dispatchStdlibIn = map[string]interface{}{
+	`test`: `package test
+
+import "io"
+
+type File struct {
+	name string
+}
+
+func (f *File) Read(p []byte) (int, error) { return 0, io.EOF }
+
+type Source struct {
+	r   io.Reader
+	err error
+}
+`,
+}
+
+var dispatchStdlibOut = map[Type]string{
+	{"File", "test"}: `// Code generated by goequal for type: File; DO NOT EDIT
+package test
+
+func EqualFile(t1, t2 *File) bool {
+	if t1 == t2 {
+		return true
+	}
+	if t1 == nil || t2 == nil {
+		return false
+	}
+	if t1.name != t2.name {
+		return false
+	}
+	return true
+}
+`,
+	{"Source", "test"}: `// Code generated by goequal for type: Source; DO NOT EDIT
+package test
+
+import "github.com/gadumitrachioaiei/goequal/equal"
+
+func EqualSource(t1, t2 *Source) bool {
+	if t1 == t2 {
+		return true
+	}
+	if t1 == nil || t2 == nil {
+		return false
+	}
+	switch v1 := t1.r.(type) {
+	case *File:
+		v2, ok := t2.r.(*File)
+		if !ok {
+			return false
+		}
+		if !EqualFile(v1, v2) {
+			return false
+		}
+	default:
+		if !equal.Interface(t1.r, t2.r) {
+			return false
+		}
+	}
+	if !equal.Interface(t1.err, t2.err) {
+		return false
+	}
+	return true
+}
+`,
+}
+
+var dispatchOut = map[Type]string{
+	{"Circle", "test"}: `// Code generated by goequal for type: Circle; DO NOT EDIT
+package test
+
+func EqualCircle(t1, t2 *Circle) bool {
+	if t1 == t2 {
+		return true
+	}
+	if t1 == nil || t2 == nil {
+		return false
+	}
+	if t1.r != t2.r {
+		return false
+	}
+	return true
+}
+`,
+	{"Square", "test"}: `// Code generated by goequal for type: Square; DO NOT EDIT
+package test
+
+func EqualSquare(t1, t2 *Square) bool {
+	if t1 == t2 {
+		return true
+	}
+	if t1 == nil || t2 == nil {
+		return false
+	}
+	if t1.side != t2.side {
+		return false
+	}
+	return true
+}
+`,
+	{"Shape", "test"}: `// Code generated by goequal for type: Shape; DO NOT EDIT
+package test
+
+import "github.com/gadumitrachioaiei/goequal/equal"
+
+func EqualShape(t1, t2 Shape) bool {
+	switch v1 := t1.(type) {
+	case *Circle:
+		v2, ok := t2.(*Circle)
+		if !ok {
+			return false
+		}
+		if !EqualCircle(v1, v2) {
+			return false
+		}
+	case Square:
+		v2, ok := t2.(Square)
+		if !ok {
+			return false
+		}
+		if !EqualSquare(&v1, &v2) {
+			return false
+		}
+	case *Square:
+		v2, ok := t2.(*Square)
+		if !ok {
+			return false
+		}
+		if !EqualSquare(v1, v2) {
+			return false
+		}
+	default:
+		if !equal.Interface(t1, t2) {
+			return false
+		}
+	}
+	return true
+}
+`,
+	{"Drawing", "test"}: `// Code generated by goequal for type: Drawing; DO NOT EDIT
+package test
+
+import "github.com/gadumitrachioaiei/goequal/equal"
+
+func EqualDrawing(t1, t2 *Drawing) bool {
+	if t1 == t2 {
+		return true
+	}
+	if t1 == nil || t2 == nil {
+		return false
+	}
+	if !EqualShape(t1.main, t2.main) {
+		return false
+	}
+	if len(t1.shapes) != len(t2.shapes) {
+		return false
+	}
+	for i1 := range t1.shapes {
+		if !EqualShape(t1.shapes[i1], t2.shapes[i1]) {
+			return false
+		}
+	}
+	if !equal.Interface(t1.meta, t2.meta) {
+		return false
+	}
+	return true
+}
+`,
+}
+
+// generated functions are registered for interface values
+var registerIn = map[string]interface{}{
+	`test`: `package test
+
+type Tags []string
+
+type Event struct {
+	name    string
+	tags    Tags
+	payload interface{}
+}
+`,
+}
+
+var registerOut = map[Type]string{
+	{"Tags", "test"}: `// Code generated by goequal for type: Tags; DO NOT EDIT
+package test
+
+import "github.com/gadumitrachioaiei/goequal/equal"
+
+func EqualTags(t1, t2 Tags) bool {
+	if len(t1) != len(t2) {
+		return false
+	}
+	for i1 := range t1 {
+		if t1[i1] != t2[i1] {
+			return false
+		}
+	}
+	return true
+}
+
+func init() {
+	equal.RegisterValue(EqualTags)
+}
+`,
+	{"Event", "test"}: `// Code generated by goequal for type: Event; DO NOT EDIT
+package test
+
+import "github.com/gadumitrachioaiei/goequal/equal"
+
+func EqualEvent(t1, t2 *Event) bool {
+	if t1 == t2 {
+		return true
+	}
+	if t1 == nil || t2 == nil {
+		return false
+	}
+	if t1.name != t2.name {
+		return false
+	}
+	if !EqualTags(t1.tags, t2.tags) {
+		return false
+	}
+	if !equal.Interface(t1.payload, t2.payload) {
+		return false
+	}
+	return true
+}
+
+func init() {
+	equal.Register(EqualEvent)
+}
+`,
+}
+
+// types whose values can form cycles pass the visited pairs to each other, other types are compared as usual
+var cycleSafeIn = map[string]interface{}{
+	`test`: `package test
+
+type Test struct {
+	Name     string
+	Next     *Test
+	Children []*Test
+	Kids     Kids
+	Tags     map[string]int
+	Leaf     Leaf
+}
+
+type Kids map[string]*Test
+
+type Leaf struct {
+	V int
+}
+`,
+}
+
+var cycleSafeOut = map[Type]string{
+	{"Kids", "test"}: `// Code generated by goequal for type: Kids; DO NOT EDIT
+package test
+
+import "fmt"
+import "github.com/gadumitrachioaiei/goequal/equal"
+
+func EqualKids(t1, t2 Kids) bool {
+	return equalKidsVisited(t1, t2, equal.Visited{})
+}
+
+func equalKidsVisited(t1, t2 Kids, visited equal.Visited) bool {
+	if visited.Visit(t1, t2) {
+		return true
+	}
+	if len(t1) != len(t2) {
+		return false
+	}
+	if !visited.Visit(t1, t2) {
+		for key1, value11 := range t1 {
+			if value12, ok := t2[key1]; !ok {
+				return false
+			} else {
+				if !equalTestVisited(value11, value12, visited) {
+					return false
+				}
+			}
+		}
+	}
+	return true
+}
+
+func DiffKids(t1, t2 Kids) []equal.Difference {
+	return diffKidsVisited(t1, t2, equal.Visited{})
+}
+
+func diffKidsVisited(t1, t2 Kids, visited equal.Visited) []equal.Difference {
+	if visited.Visit(t1, t2) {
+		return nil
+	}
+	var diffs []equal.Difference
+	if !visited.Visit(t1, t2) {
+		for key1, value11 := range t1 {
+			if value12, ok := t2[key1]; !ok {
+				diffs = append(diffs, equal.Difference{Path: fmt.Sprintf("[%#v]", key1), Value1: fmt.Sprint(value11), Value2: "", Reason: equal.MissingKey})
+			} else {
+				diffs = equal.Nested(diffs, fmt.Sprintf("[%#v]", key1), diffTestVisited(value11, value12, visited))
+			}
+		}
+		for key1, value12 := range t2 {
+			if _, ok := t1[key1]; !ok {
+				diffs = append(diffs, equal.Difference{Path: fmt.Sprintf("[%#v]", key1), Value1: "", Value2: fmt.Sprint(value12), Reason: equal.MissingKey})
+			}
+		}
+	}
+	return diffs
+}
+`,
+	{"Leaf", "test"}: `// Code generated by goequal for type: Leaf; DO NOT EDIT
+package test
+
+import "fmt"
+import "github.com/gadumitrachioaiei/goequal/equal"
+
+func EqualLeaf(t1, t2 *Leaf) bool {
+	if t1 == t2 {
+		return true
+	}
+	if t1 == nil || t2 == nil {
+		return false
+	}
+	if t1.V != t2.V {
+		return false
+	}
+	return true
+}
+
+func DiffLeaf(t1, t2 *Leaf) []equal.Difference {
+	if t1 == t2 {
+		return nil
+	}
+	if t1 == nil || t2 == nil {
+		return []equal.Difference{{Value1: fmt.Sprint(t1), Value2: fmt.Sprint(t2), Reason: equal.NilValue}}
+	}
+	var diffs []equal.Difference
+	if t1.V != t2.V {
+		diffs = append(diffs, equal.Difference{Path: "V", Value1: fmt.Sprint(t1.V), Value2: fmt.Sprint(t2.V), Reason: equal.DifferentValues})
+	}
+	return diffs
+}
+`,
+	{"Test", "test"}: `// Code generated by goequal for type: Test; DO NOT EDIT
+package test
+
+import "fmt"
+import "github.com/gadumitrachioaiei/goequal/equal"
+
+func EqualTest(t1, t2 *Test) bool {
+	return equalTestVisited(t1, t2, equal.Visited{})
+}
+
+func equalTestVisited(t1, t2 *Test, visited equal.Visited) bool {
+	for {
+		if t1 == t2 {
+			return true
+		}
+		if t1 == nil || t2 == nil {
+			return false
+		}
+		if visited.Visit(t1, t2) {
+			return true
+		}
+		if t1.Name != t2.Name {
+			return false
+		}
+		if len(t1.Children) != len(t2.Children) {
+			return false
+		}
+		for i1 := range t1.Children {
+			if !equalTestVisited(t1.Children[i1], t2.Children[i1], visited) {
+				return false
+			}
+		}
+		if !equalKidsVisited(t1.Kids, t2.Kids, visited) {
+			return false
+		}
+		if len(t1.Tags) != len(t2.Tags) {
+			return false
+		}
+		for key1, value11 := range t1.Tags {
+			if value12, ok := t2.Tags[key1]; !ok {
+				return false
+			} else {
+				if value11 != value12 {
+					return false
+				}
+			}
+		}
+		if !EqualLeaf((&t1.Leaf), (&t2.Leaf)) {
+			return false
+		}
+		t1, t2 = t1.Next, t2.Next
+	}
+}
+
+func DiffTest(t1, t2 *Test) []equal.Difference {
+	return diffTestVisited(t1, t2, equal.Visited{})
+}
+
+func diffTestVisited(t1, t2 *Test, visited equal.Visited) []equal.Difference {
+	var chain []equal.Difference
+	for depth := 0; ; depth++ {
+		if t1 == t2 {
+			return chain
+		}
+		if t1 == nil || t2 == nil {
+			return equal.Nested(chain, equal.ChainPath("Next", depth), []equal.Difference{{Value1: fmt.Sprint(t1), Value2: fmt.Sprint(t2), Reason: equal.NilValue}})
+		}
+		if visited.Visit(t1, t2) {
+			return chain
+		}
+		var diffs []equal.Difference
+		if t1.Name != t2.Name {
+			diffs = append(diffs, equal.Difference{Path: "Name", Value1: fmt.Sprint(t1.Name), Value2: fmt.Sprint(t2.Name), Reason: equal.DifferentValues})
+		}
+		if len(t1.Children) != len(t2.Children) {
+			diffs = append(diffs, equal.Difference{Path: "Children", Value1: fmt.Sprint(len(t1.Children)), Value2: fmt.Sprint(len(t2.Children)), Reason: equal.DifferentLengths})
+		} else {
+			for i1 := range t1.Children {
+				diffs = equal.Nested(diffs, fmt.Sprintf("Children[%d]", i1), diffTestVisited(t1.Children[i1], t2.Children[i1], visited))
+			}
+		}
+		diffs = equal.Nested(diffs, "Kids", diffKidsVisited(t1.Kids, t2.Kids, visited))
+		for key1, value11 := range t1.Tags {
+			if value12, ok := t2.Tags[key1]; !ok {
+				diffs = append(diffs, equal.Difference{Path: fmt.Sprintf("Tags[%#v]", key1), Value1: fmt.Sprint(value11), Value2: "", Reason: equal.MissingKey})
+			} else {
+				if value11 != value12 {
+					diffs = append(diffs, equal.Difference{Path: fmt.Sprintf("Tags[%#v]", key1), Value1: fmt.Sprint(value11), Value2: fmt.Sprint(value12), Reason: equal.DifferentValues})
+				}
+			}
+		}
+		for key1, value12 := range t2.Tags {
+			if _, ok := t1.Tags[key1]; !ok {
+				diffs = append(diffs, equal.Difference{Path: fmt.Sprintf("Tags[%#v]", key1), Value1: "", Value2: fmt.Sprint(value12), Reason: equal.MissingKey})
+			}
+		}
+		diffs = equal.Nested(diffs, "Leaf", DiffLeaf((&t1.Leaf), (&t2.Leaf)))
+		if len(diffs) > 0 {
+			chain = equal.Nested(chain, equal.ChainPath("Next", depth), diffs)
+		}
+		t1, t2 = t1.Next, t2.Next
+	}
+}
+`,
+}
+
+func TestGoldenC(t *testing.T) {
+	testGoldenC(t, goldenC, Options{})
+}
+
+// TestGoldenMethod tests generated Equal methods
+func TestGoldenMethod(t *testing.T) {
+	testGoldenC(t, goldenMethod, Options{Method: true})
+}
+
+// TestGoldenDiff tests generated Diff functions
+func TestGoldenDiff(t *testing.T) {
+	testGoldenC(t, goldenDiff, Options{Diff: true})
+}
+
+// TestGoldenHash tests generated Hash functions
+func TestGoldenHash(t *testing.T) {
+	testGoldenC(t, goldenHash, Options{Hash: true})
+}
+
+// TestGoldenClone tests generated Clone functions
+func TestGoldenClone(t *testing.T) {
+	testGoldenC(t, goldenClone, Options{Clone: true})
+}
+
+// TestGoldenNaN tests generated functions with NaN values equal to each other
+func TestGoldenNaN(t *testing.T) {
+	testGoldenC(t, goldenNaN, Options{NaNEqual: true, Hash: true})
+}
+
+// TestGoldenSemantic tests generated functions with nil pointers equal to pointers to zero values
+func TestGoldenSemantic(t *testing.T) {
+	testGoldenC(t, goldenSemantic, Options{Semantic: true, Hash: true})
+}
+
+// TestGoldenDispatch tests generated functions with interface values compared with the functions of their dynamic types
+func TestGoldenDispatch(t *testing.T) {
+	testGoldenC(t, goldenDispatch, Options{Dispatch: true})
+}
+
+// TestGoldenRegister tests generated functions registered for interface values
+func TestGoldenRegister(t *testing.T) {
+	testGoldenC(t, goldenRegister, Options{Register: true})
+}
+
+// TestGoldenCycleSafe tests generated functions detecting cycles
+func TestGoldenCycleSafe(t *testing.T) {
+	testGoldenC(t, goldenCycleSafe, Options{CycleSafe: true, Diff: true})
+}
+
+// testGoldenC runs complex tests with the given options
+func testGoldenC(t *testing.T, tests []GoldenComplex, options Options) {
+	for _, test := range tests {
+		// I will compile the generated code for extra checking
+		g := NewGenerator(test.pkgPath, test.typ, false, test.input, options)
+		if err := g.parse(); err != nil {
+			t.Errorf("test: %s, unexpected error: %s", test.name, err)
+			continue
+		}
+		success, files, inputs := assertComplex(t, g, test)
+		// we can compile only code of one package
+		if success && len(test.input) == 1 {
+			files = append(files, "test.go")
+			inputs = append(inputs, test.input[test.pkgPath])
+			if err := compile(files, inputs); err != nil {
+				t.Errorf("test: %s, code not compilable: %s", test.name, err)
+			}
+		}
+
+	}
+}
+
+// assertComplex asserts that a complex test passes
+// returns success, list of files and their containing code
+func assertComplex(t *testing.T, g *Generator, test GoldenComplex) (success bool, files []string, inputs []interface{}) {
+	success = true
+	counter := 0
+	for expectedType, expectedCode := range test.output {
+		code := g.equals[expectedType]
+		if code == nil {
+			t.Errorf("expected code for type: %v", expectedType)
+			success = false
+		} else {
+			_, calculatedCode, err := code.serialize()
+			if err != nil {
+				t.Errorf("test: %s, type: %v, %s", test.name, expectedType, err)
+			}
 			if expectedCode != string(calculatedCode) {
 				t.Errorf("test: %s, type: %v, expected: \n%s, found: \n%s", test.name, expectedType, expectedCode, string(calculatedCode))
 				success = false
@@ -6,14 +6,12 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/gadumitrachioaiei/goequal/equal/testdata"
 )
 
-// GOPATH should consist of only one folder
-var GOPATH = os.Getenv("GOPATH")
-
 var goldenD = []GoldenComplex{
 	{"packages", "X", "test", packagesIn, packagesOut},
 	{"alias", "X", "test", aliasIn, aliasOut},
@@ -21,7 +19,7 @@ var goldenD = []GoldenComplex{
 	{"cgo", "X", "test", cgoIn, cgoOut},
 }
 
-// types in multiple packages
+// types in multiple modules, the type of the other module gets a helper in the calling package
 var packagesIn = map[string]interface{}{
 	`test`: `package test
 import "test2"
@@ -38,8 +36,6 @@ var packagesOut = map[Type]string{
 	{"X", "test"}: `// Code generated by goequal for type: X; DO NOT EDIT
 package test
 
-import "test2"
-
 func EqualX(t1, t2 *X) bool {
 	if t1 == t2 {
 		return true
@@ -47,16 +43,18 @@ func EqualX(t1, t2 *X) bool {
 	if t1 == nil || t2 == nil {
 		return false
 	}
-	if !test2.EqualY(t1.b, t2.b) {
+	if !equal_thirdparty_Y(t1.b, t2.b) {
 		return false
 	}
 	return true
 }
 `,
-	{"Y", "test2"}: `// Code generated by goequal for type: Y; DO NOT EDIT
-package test2
+	{"thirdparty_Y", "test"}: `// Code generated by goequal for type: thirdparty_Y; DO NOT EDIT
+package test
 
-func EqualY(t1, t2 Y) bool {
+import "test2"
+
+func equal_thirdparty_Y(t1, t2 test2.Y) bool {
 	if t1 != t2 {
 		return false
 	}
@@ -82,8 +80,6 @@ var aliasOut = map[Type]string{
 	{"X", "test"}: `// Code generated by goequal for type: X; DO NOT EDIT
 package test
 
-import alias "test2"
-
 func EqualX(t1, t2 *X) bool {
 	if t1 == t2 {
 		return true
@@ -91,16 +87,18 @@ func EqualX(t1, t2 *X) bool {
 	if t1 == nil || t2 == nil {
 		return false
 	}
-	if !alias.EqualY(t1.b, t2.b) {
+	if !equal_thirdparty_Y(t1.b, t2.b) {
 		return false
 	}
 	return true
 }
 `,
-	{"Y", "test2"}: `// Code generated by goequal for type: Y; DO NOT EDIT
-package test2
+	{"thirdparty_Y", "test"}: `// Code generated by goequal for type: thirdparty_Y; DO NOT EDIT
+package test
+
+import alias "test2"
 
-func EqualY(t1, t2 Y) bool {
+func equal_thirdparty_Y(t1, t2 alias.Y) bool {
 	if t1 != t2 {
 		return false
 	}
@@ -126,8 +124,6 @@ var dotImportOut = map[Type]string{
 	{"X", "test"}: `// Code generated by goequal for type: X; DO NOT EDIT
 package test
 
-import . "test2"
-
 func EqualX(t1, t2 *X) bool {
 	if t1 == t2 {
 		return true
@@ -135,16 +131,18 @@ func EqualX(t1, t2 *X) bool {
 	if t1 == nil || t2 == nil {
 		return false
 	}
-	if !EqualY(t1.b, t2.b) {
+	if !equal_thirdparty_Y(t1.b, t2.b) {
 		return false
 	}
 	return true
 }
 `,
-	{"Y", "test2"}: `// Code generated by goequal for type: Y; DO NOT EDIT
-package test2
+	{"thirdparty_Y", "test"}: `// Code generated by goequal for type: thirdparty_Y; DO NOT EDIT
+package test
+
+import . "test2"
 
-func EqualY(t1, t2 Y) bool {
+func equal_thirdparty_Y(t1, t2 Y) bool {
 	if t1 != t2 {
 		return false
 	}
@@ -180,8 +178,6 @@ var cgoOut = map[Type]string{
 	{"X", "test"}: `// Code generated by goequal for type: X; DO NOT EDIT
 package test
 
-import "test2"
-
 func EqualX(t1, t2 *X) bool {
 	if t1 == t2 {
 		return true
@@ -189,7 +185,7 @@ func EqualX(t1, t2 *X) bool {
 	if t1 == nil || t2 == nil {
 		return false
 	}
-	if !test2.EqualY(t1.a, t2.a) {
+	if !equal_thirdparty_Y(t1.a, t2.a) {
 		return false
 	}
 	return true
@@ -197,82 +193,838 @@ func EqualX(t1, t2 *X) bool {
 `,
 }
 
+// goldenDModules describes the modules holding test and test2 packages.
+// test2 is a separate module, required through a replace directive.
+var goldenDModules = map[string]string{
+	"test":  "module test\n\ngo 1.21\n\nrequire test2 v0.0.0\n\nreplace test2 => ./test2\n",
+	"test2": "module test2\n\ngo 1.21\n",
+}
+
 // TestGoldenD tests generated code written to disk
-// if the test fails you have to run the test again for cleanup or manually remove the test and test2 directories
+// Packages are written in a temporary module, without installing them first.
 func TestGoldenD(t *testing.T) {
-	t.Skip("Skip test that writes to disk")
+	if testing.Short() {
+		t.Skip("Skip test that writes to disk")
+	}
 	for _, test := range goldenD {
-		// write to disk
-		if GOPATH == "" {
-			t.Fatalf("No GOPATH")
-		}
-		failed := false
-		names := []string{"test", "test2"}
-		pkgPaths := []string{filepath.Join(GOPATH, "src", "test"), filepath.Join(GOPATH, "src", "test2")}
-		// write the code to disk
-		for i, pkgPath := range pkgPaths {
-			if _, err := os.Stat(pkgPath); err == nil {
-				t.Errorf("path: %s exists", pkgPath)
-				failed = true
-				break
-			} else {
-				if !os.IsNotExist(err) {
-					t.Errorf("path: %s could not be asserted: %s", pkgPath, err)
-					failed = true
-					break
+		root := t.TempDir()
+		names, pkgPaths := []string{"test", "test2"}, writeModules(t, root, test)
+		t.Chdir(root)
+		// call the generator and assert
+		g := NewGenerator(".", test.typ, false, nil, Options{})
+		if err := g.Generate(); err != nil {
+			t.Errorf("test: %s, unexpected error: %s", test.name, err)
+			continue
+		}
+		if assertDisk(t, pkgPaths, test) {
+			// compile the code
+			for _, name := range names {
+				if out, err := exec.Command("go", "build", name).CombinedOutput(); err != nil {
+					t.Errorf("Error compiling package: %s %s %s", name, err, out)
 				}
 			}
-			if err := os.Mkdir(pkgPath, 0750); err != nil {
-				t.Errorf("Can not create dir: %s %s", pkgPath, err)
-				failed = true
-				break
-			}
-			filePath := filepath.Join(pkgPath, "test.go")
-			content := []byte(test.input[names[i]].(string))
-			if err := ioutil.WriteFile(filePath, content, 0750); err != nil {
-				t.Errorf("Can not create file:%s %s", filePath, err)
-				failed = true
-				break
+		}
+	}
+}
+
+// writeModules writes the test and test2 packages of the test, each in its own module, in root.
+// It returns the directories of the packages.
+func writeModules(t *testing.T, root string, test GoldenComplex) []string {
+	names := []string{"test", "test2"}
+	pkgPaths := []string{root, filepath.Join(root, "test2")}
+	for i, pkgPath := range pkgPaths {
+		if err := os.MkdirAll(pkgPath, 0750); err != nil {
+			t.Fatalf("Can not create dir: %s %s", pkgPath, err)
+		}
+		files := map[string][]byte{
+			"go.mod":  []byte(goldenDModules[names[i]]),
+			"test.go": []byte(test.input[names[i]].(string)),
+		}
+		for fileName, content := range files {
+			filePath := filepath.Join(pkgPath, fileName)
+			if err := ioutil.WriteFile(filePath, content, 0640); err != nil {
+				t.Fatalf("Can not create file:%s %s", filePath, err)
 			}
 		}
-		if failed {
-			removePackages(t, pkgPaths)
-			return
+	}
+	return pkgPaths
+}
+
+// singleFileIn has two types in a package, with different imports
+var singleFileIn = map[string]interface{}{
+	`test`: `package test
+import "test2"
+type X struct {
+	b test2.Y
+	z Z
+}
+type Z []byte
+type W struct {
+	n int
+}
+`,
+	`test2`: `package test2
+type Y int
+`,
+}
+
+// singleFileOut has the files of the packages, named as the types they would have in a file per type
+var singleFileOut = map[Type]string{
+	{"equal", "test"}: `// Code generated by goequal for types: X, Z, thirdparty_Y; DO NOT EDIT
+package test
+
+import "bytes"
+import "test2"
+
+func EqualX(t1, t2 *X) bool {
+	if t1 == t2 {
+		return true
+	}
+	if t1 == nil || t2 == nil {
+		return false
+	}
+	if !equal_thirdparty_Y(t1.b, t2.b) {
+		return false
+	}
+	if !EqualZ(t1.z, t2.z) {
+		return false
+	}
+	return true
+}
+
+func EqualZ(t1, t2 Z) bool {
+	if !bytes.Equal(t1, t2) {
+		return false
+	}
+	return true
+}
+
+func equal_thirdparty_Y(t1, t2 test2.Y) bool {
+	if t1 != t2 {
+		return false
+	}
+	return true
+}
+`,
+}
+
+// TestSingleFile tests that the functions of every package are written in a single file,
+// that the files of the types, generated before, are removed, and that the types of a single file, generated before, are kept.
+func TestSingleFile(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skip test that writes to disk")
+	}
+	test := GoldenComplex{"singleFile", "X", "test", singleFileIn, singleFileOut}
+	root := t.TempDir()
+	pkgPaths := writeModules(t, root, test)
+	t.Chdir(root)
+	if err := NewGenerator(".", test.typ, false, nil, Options{}).Generate(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if err := NewGenerator(".", test.typ, false, nil, Options{SingleFile: true}).Generate(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !assertDisk(t, pkgPaths, test) {
+		return
+	}
+	for _, typeFile := range []string{filepath.Join(pkgPaths[0], "goequal_X.go"), filepath.Join(pkgPaths[0], "goequal_Z.go"), filepath.Join(pkgPaths[0], "goequal_thirdparty_Y.go")} {
+		if _, err := os.Stat(typeFile); !os.IsNotExist(err) {
+			t.Errorf("file: %s, expected to be removed, found: %v", typeFile, err)
 		}
-		// first we need to install test package: https://github.com/golang/go/issues/14496
-		if err := exec.Command("go", "install", "test").Run(); err != nil {
-			t.Errorf("can not install package test: %s", err)
-			removePackages(t, pkgPaths)
-			return
+	}
+	for _, name := range []string{"test", "test2"} {
+		if out, err := exec.Command("go", "build", name).CombinedOutput(); err != nil {
+			t.Errorf("Error compiling package: %s %s %s", name, err, out)
 		}
-		// call the generator and assert
-		g := NewGenerator(test.pkgPath, test.typ, false, nil)
-		g.Generate()
-		if assertDisk(t, g, test) {
-			// compile the code
-			for _, name := range names {
-				if err := exec.Command("go", "build", name).Run(); err != nil {
-					t.Errorf("Error compiling package: %s %s", name, err)
-				}
+	}
+	// the functions of the types generated before are kept in the single file
+	if err := NewGenerator(".", "W", false, nil, Options{SingleFile: true}).Generate(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	packageFile := filepath.Join(pkgPaths[0], packageFileName)
+	if typeNames, err := readGeneratedTypes(packageFile); err != nil || strings.Join(typeNames, ", ") != "W, X, Z, thirdparty_Y" {
+		t.Errorf("expected types: W, X, Z, thirdparty_Y, found: %v %v", typeNames, err)
+	}
+	// and in a file per type, replacing the single file
+	if err := NewGenerator(".", "W", false, nil, Options{}).Generate(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if _, err := os.Stat(packageFile); !os.IsNotExist(err) {
+		t.Errorf("file: %s, expected to be removed, found: %v", packageFile, err)
+	}
+	for _, typeName := range []string{"W", "X", "Z", "thirdparty_Y"} {
+		if _, err := os.Stat(filepath.Join(pkgPaths[0], fmt.Sprintf("goequal_%s.go", typeName))); err != nil {
+			t.Errorf("type: %s, expected its file, found: %v", typeName, err)
+		}
+	}
+	if out, err := exec.Command("go", "build", "test").CombinedOutput(); err != nil {
+		t.Errorf("Error compiling package: test %s %s", err, out)
+	}
+	// types which can not be generated again are reported, and nothing is written
+	if err := ioutil.WriteFile(packageFile, []byte("// Code generated by goequal for types: Gone, W; DO NOT EDIT\npackage test\n"), 0640); err != nil {
+		t.Fatal(err)
+	}
+	err := NewGenerator(".", "W", false, nil, Options{SingleFile: true}).Generate()
+	if expected := "file:" + packageFile + " has the functions of Gone, which are not generated now, generate them too or remove the file"; err == nil || err.Error() != expected {
+		t.Errorf("expected error: %s, found: %v", expected, err)
+	}
+	if _, err := os.Stat(filepath.Join(pkgPaths[0], "goequal_W.go")); err != nil {
+		t.Errorf("expected the file of W to be kept, found: %v", err)
+	}
+}
+
+// outIn has types of packages with the same name, and types with the same name
+var outIn = map[string]interface{}{
+	`test`: `package test
+import (
+	billing "test/billing/model"
+	shipping "test/shipping/model"
+	"test2"
+)
+type X struct {
+	Name     string
+	Y        test2.Y
+	Invoice  billing.Order
+	Shipment *shipping.Order
+	cache    int ` + "`goequal:\"-\"`" + `
+}
+`,
+	`test2`: `package test2
+type Y []int
+`,
+	`test/billing/model`: `package model
+type Order struct {
+	Total float64
+}
+`,
+	`test/shipping/model`: `package model
+type Order struct {
+	ID int
+}
+`,
+}
+
+var outOut = `// Code generated by goequal for types: Order, Order_2, X, Y; DO NOT EDIT
+package equality
+
+import "test"
+import "test/billing/model"
+import model2 "test/shipping/model"
+import "test2"
+
+func EqualOrder(t1, t2 *model.Order) bool {
+	if t1 == t2 {
+		return true
+	}
+	if t1 == nil || t2 == nil {
+		return false
+	}
+	if t1.Total != t2.Total {
+		return false
+	}
+	return true
+}
+
+func EqualOrder_2(t1, t2 *model2.Order) bool {
+	if t1 == t2 {
+		return true
+	}
+	if t1 == nil || t2 == nil {
+		return false
+	}
+	if t1.ID != t2.ID {
+		return false
+	}
+	return true
+}
+
+func EqualX(t1, t2 *test.X) bool {
+	if t1 == t2 {
+		return true
+	}
+	if t1 == nil || t2 == nil {
+		return false
+	}
+	if t1.Name != t2.Name {
+		return false
+	}
+	if !EqualY(t1.Y, t2.Y) {
+		return false
+	}
+	if !EqualOrder((&t1.Invoice), (&t2.Invoice)) {
+		return false
+	}
+	if !EqualOrder_2(t1.Shipment, t2.Shipment) {
+		return false
+	}
+	return true
+}
+
+func EqualY(t1, t2 test2.Y) bool {
+	if len(t1) != len(t2) {
+		return false
+	}
+	for i1 := range t1 {
+		if t1[i1] != t2[i1] {
+			return false
+		}
+	}
+	return true
+}
+`
+
+// TestOut tests that every function is written in the output package, and that nothing is written in the packages of the types
+func TestOut(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skip test that writes to disk")
+	}
+	test := GoldenComplex{"out", "X", "test", outIn, nil}
+	root := t.TempDir()
+	writeModules(t, root, test)
+	for _, pkgPath := range []string{"test/billing/model", "test/shipping/model"} {
+		dir := filepath.Join(root, strings.TrimPrefix(pkgPath, "test/"))
+		if err := os.MkdirAll(dir, 0750); err != nil {
+			t.Fatalf("Can not create dir: %s %s", dir, err)
+		}
+		if err := ioutil.WriteFile(filepath.Join(dir, "model.go"), []byte(test.input[pkgPath].(string)), 0640); err != nil {
+			t.Fatalf("Can not create file: %s", err)
+		}
+	}
+	t.Chdir(root)
+	if err := NewGenerator(".", test.typ, false, nil, Options{Out: "./internal/equality", SingleFile: true}).Generate(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	content, err := ioutil.ReadFile(filepath.Join(root, "internal", "equality", packageFileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != outOut {
+		t.Errorf("expected: \n%s, found: \n%s", outOut, content)
+	}
+	generated, err := filepath.Glob(filepath.Join(root, "*", "goequal_*.go"))
+	if err != nil || len(generated) > 0 {
+		t.Errorf("expected no files in the packages of the types, found: %v %v", generated, err)
+	}
+	if out, err := exec.Command("go", "build", "./...").CombinedOutput(); err != nil {
+		t.Errorf("Error compiling packages: %s %s", err, out)
+	}
+}
+
+// thirdPartyIn has types of another module, with fields that are not exported
+var thirdPartyIn = map[string]interface{}{
+	`test`: `package test
+import "test2"
+type X struct {
+	y test2.Y
+	z *test2.Z
+}
+`,
+	`test2`: `package test2
+type Y struct {
+	Name string
+	id   int
+}
+type Z struct {
+	Count int
+	Next  *Z
+}
+`,
+}
+
+// thirdPartySkipOut has the helpers comparing only the exported fields of the types of the other module
+var thirdPartySkipOut = `// Code generated by goequal for types: X, thirdparty_Y, thirdparty_Z; DO NOT EDIT
+package test
+
+import "test2"
+
+func EqualX(t1, t2 *X) bool {
+	if t1 == t2 {
+		return true
+	}
+	if t1 == nil || t2 == nil {
+		return false
+	}
+	if !equal_thirdparty_Y((&t1.y), (&t2.y)) {
+		return false
+	}
+	if !equal_thirdparty_Z(t1.z, t2.z) {
+		return false
+	}
+	return true
+}
+
+func equal_thirdparty_Y(t1, t2 *test2.Y) bool {
+	if t1 == t2 {
+		return true
+	}
+	if t1 == nil || t2 == nil {
+		return false
+	}
+	if t1.Name != t2.Name {
+		return false
+	}
+	return true
+}
+
+func equal_thirdparty_Z(t1, t2 *test2.Z) bool {
+	for {
+		if t1 == t2 {
+			return true
+		}
+		if t1 == nil || t2 == nil {
+			return false
+		}
+		if t1.Count != t2.Count {
+			return false
+		}
+		t1, t2 = t1.Next, t2.Next
+	}
+}
+`
+
+// thirdPartyReflectOut has the helpers comparing the types of the other module with fields that are not exported by reflect.DeepEqual
+var thirdPartyReflectOut = `// Code generated by goequal for types: X, thirdparty_Y, thirdparty_Z; DO NOT EDIT
+package test
+
+import "reflect"
+import "test2"
+
+func EqualX(t1, t2 *X) bool {
+	if t1 == t2 {
+		return true
+	}
+	if t1 == nil || t2 == nil {
+		return false
+	}
+	if !equal_thirdparty_Y((&t1.y), (&t2.y)) {
+		return false
+	}
+	if !equal_thirdparty_Z(t1.z, t2.z) {
+		return false
+	}
+	return true
+}
+
+func equal_thirdparty_Y(t1, t2 *test2.Y) bool {
+	if t1 == t2 {
+		return true
+	}
+	if t1 == nil || t2 == nil {
+		return false
+	}
+	if !reflect.DeepEqual((*t1), (*t2)) {
+		return false
+	}
+	return true
+}
+
+func equal_thirdparty_Z(t1, t2 *test2.Z) bool {
+	for {
+		if t1 == t2 {
+			return true
+		}
+		if t1 == nil || t2 == nil {
+			return false
+		}
+		if t1.Count != t2.Count {
+			return false
+		}
+		t1, t2 = t1.Next, t2.Next
+	}
+}
+`
+
+// TestThirdParty tests that the functions of types of another module are private helpers of the calling package,
+// that nothing is written in the other module and that fields that are not exported are handled by the Unexported strategy.
+func TestThirdParty(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skip test that writes to disk")
+	}
+	tests := []struct {
+		unexported, output, err string
+	}{
+		{"", "", "field id of test2.Y is not exported, it can not be compared outside package test2"},
+		{UnexportedSkip, thirdPartySkipOut, ""},
+		{UnexportedReflect, thirdPartyReflectOut, ""},
+	}
+	for _, test := range tests {
+		root := t.TempDir()
+		pkgPaths := writeModules(t, root, GoldenComplex{"thirdParty", "X", "test", thirdPartyIn, nil})
+		t.Chdir(root)
+		err := NewGenerator(".", "X", false, nil, Options{SingleFile: true, Unexported: test.unexported}).Generate()
+		if test.err != "" {
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Errorf("strategy: %q, expected error: %s, found: %v", test.unexported, test.err, err)
 			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("strategy: %q, unexpected error: %s", test.unexported, err)
+			continue
+		}
+		content, err := ioutil.ReadFile(filepath.Join(root, packageFileName))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(content) != test.output {
+			t.Errorf("strategy: %q, expected: \n%s, found: \n%s", test.unexported, test.output, content)
+		}
+		if generated, err := filepath.Glob(filepath.Join(pkgPaths[1], "goequal_*.go")); err != nil || len(generated) > 0 {
+			t.Errorf("strategy: %q, expected no files in the other module, found: %v %v", test.unexported, generated, err)
+		}
+		if out, err := exec.Command("go", "build", "test").CombinedOutput(); err != nil {
+			t.Errorf("Error compiling package: %s %s", err, out)
+		}
+	}
+	// the functions of types of other modules can be generated only in the main module
+	if err := NewGenerator("test2", "Y", false, nil, Options{}).Generate(); err == nil || !strings.Contains(err.Error(), "package test2 is not in the main module") {
+		t.Errorf("expected error for a package of another module, found: %v", err)
+	}
+}
+
+// TestPackagePattern tests that the annotated types of every package of the module are generated in one run, each one in its package.
+func TestPackagePattern(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skip test that writes to disk")
+	}
+	root := t.TempDir()
+	files := map[string]string{
+		"go.mod":         "module test\n\ngo 1.21\n",
+		"invoice.go":     "package test\nimport \"test/model\"\n//goequal:generate\ntype Invoice struct {\n\tOrder model.Order\n}\n",
+		"model/order.go": "package model\n//goequal:generate\ntype Order struct {\n\tLines []Line\n}\ntype Line struct {\n\tQty int\n}\n",
+		"empty/empty.go": "package empty\ntype Empty struct{}\n",
+	}
+	writeFiles(t, root, files)
+	t.Chdir(root)
+	if err := NewGenerator("./...", "", false, nil, Options{AllAnnotated: true}).Generate(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	generated, err := filepath.Glob(filepath.Join(root, "*", "goequal_*.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	generatedRoot, err := filepath.Glob(filepath.Join(root, "goequal_*.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{
+		filepath.Join(root, "goequal_Invoice.go"),
+		filepath.Join(root, "model", "goequal_Line.go"),
+		filepath.Join(root, "model", "goequal_Order.go"),
+	}
+	if found := append(generatedRoot, generated...); strings.Join(found, " ") != strings.Join(expected, " ") {
+		t.Errorf("expected files: %v, found: %v", expected, found)
+	}
+	if out, err := exec.Command("go", "build", "./...").CombinedOutput(); err != nil {
+		t.Errorf("Error compiling packages: %s %s", err, out)
+	}
+}
+
+// outPatternOut has the functions of the types of two packages, the type of the first one reached also from the second one
+var outPatternOut = `// Code generated by goequal for types: Line, Order; DO NOT EDIT
+package equality
+
+import "test/a/model"
+import model2 "test/b/model"
+
+func EqualLine(t1, t2 *model.Line) bool {
+	if t1 == t2 {
+		return true
+	}
+	if t1 == nil || t2 == nil {
+		return false
+	}
+	if t1.Qty != t2.Qty {
+		return false
+	}
+	return true
+}
+
+func EqualOrder(t1, t2 *model2.Order) bool {
+	if t1 == t2 {
+		return true
+	}
+	if t1 == nil || t2 == nil {
+		return false
+	}
+	if len(t1.Lines) != len(t2.Lines) {
+		return false
+	}
+	for i1 := range t1.Lines {
+		if !EqualLine((&t1.Lines[i1]), (&t2.Lines[i1])) {
+			return false
 		}
-		// remove the code from disk
-		removePackages(t, pkgPaths)
 	}
+	return true
 }
+`
+
+// TestOutPackagePattern tests that a type of a package reached from another package, both matched by a pattern, gets one function.
+func TestOutPackagePattern(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skip test that writes to disk")
+	}
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"go.mod":           "module test\n\ngo 1.21\n",
+		"a/model/line.go":  "package model\n//goequal:generate\ntype Line struct {\n\tQty int\n\tnote string\n}\n",
+		"b/model/order.go": "package model\nimport amodel \"test/a/model\"\n//goequal:generate\ntype Order struct {\n\tLines []amodel.Line\n}\n",
+	})
+	t.Chdir(root)
+	options := Options{AllAnnotated: true, Out: "./internal/equality", Unexported: UnexportedSkip, SingleFile: true}
+	if err := NewGenerator("./...", "", false, nil, options).Generate(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	content, err := ioutil.ReadFile(filepath.Join(root, "internal", "equality", packageFileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != outPatternOut {
+		t.Errorf("expected: \n%s, found: \n%s", outPatternOut, content)
+	}
+	if out, err := exec.Command("go", "build", "./...").CombinedOutput(); err != nil {
+		t.Errorf("Error compiling packages: %s %s", err, out)
+	}
+}
+
+// cyclesIn has types whose values form cycles through a slice and through a map, and a program comparing such values
+var cyclesIn = map[string]string{
+	"model/model.go": `package model
+
+type Node struct {
+	V        int
+	Children []Node
+}
+
+type T struct {
+	V int
+	M map[string]T
+}
+`,
+	"main.go": `package main
+
+import (
+	"fmt"
 
-func removePackages(t *testing.T, pkgPaths []string) {
-	for _, pkgPath := range pkgPaths {
-		if err := os.RemoveAll(pkgPath); err != nil {
-			t.Errorf("Can not delete dir: %s %s", pkgPath, err)
+	"test/model"
+)
+
+func main() {
+	s1, s2, s3 := make([]model.Node, 1), make([]model.Node, 1), make([]model.Node, 1)
+	s1[0].Children, s2[0].Children, s3[0] = s1, s2, model.Node{V: 1, Children: s3}
+	fmt.Println(model.EqualNode(&s1[0], &s2[0]), model.EqualNode(&s1[0], &s3[0]))
+	m1, m2, m3 := map[string]model.T{}, map[string]model.T{}, map[string]model.T{}
+	m1["a"], m2["a"], m3["a"] = model.T{M: m1}, model.T{M: m2}, model.T{V: 1, M: m3}
+	fmt.Println(model.EqualT(&model.T{M: m1}, &model.T{M: m2}), model.EqualT(&model.T{M: m1}, &model.T{M: m3}))
+	fmt.Println(len(model.DiffT(&model.T{M: m1}, &model.T{M: m2})), len(model.DiffT(&model.T{M: m1}, &model.T{V: 1, M: m2})))
+}
+`,
+}
+
+// TestCycleSafeRuntime tests that the generated functions end for values forming cycles through slices and maps,
+// whose values are copied before being compared.
+func TestCycleSafeRuntime(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skip test that writes to disk")
+	}
+	out := runModule(t, cyclesIn, "Node,T", Options{CycleSafe: true, Diff: true})
+	if expected := "true false\ntrue false\n0 1\n"; out != expected {
+		t.Errorf("expected: \n%s, found: \n%s", expected, out)
+	}
+}
+
+// keyedIn has a slice paired by key, and a program comparing slices with duplicate keys
+var keyedIn = map[string]string{
+	"model/model.go": `package model
+
+type Item struct {
+	ID, V int
+}
+
+type X struct {
+	Items []*Item ` + "`" + `goequal:"key=ID"` + "`" + `
+}
+`,
+	"main.go": `package main
+
+import (
+	"fmt"
+
+	"test/model"
+)
+
+func main() {
+	x := &model.X{Items: []*model.Item{{ID: 1, V: 1}, {ID: 1, V: 2}, {ID: 2}}}
+	fmt.Println(model.EqualX(x, model.CloneX(x)), len(model.DiffX(x, model.CloneX(x))))
+	y := &model.X{Items: []*model.Item{{ID: 2}, {ID: 1, V: 1}, {ID: 1, V: 2}}}
+	fmt.Println(model.EqualX(x, y), len(model.DiffX(x, y)))
+	y = &model.X{Items: []*model.Item{{ID: 1, V: 2}, {ID: 1, V: 1}, {ID: 2}}}
+	fmt.Println(model.EqualX(x, y), len(model.DiffX(x, y)))
+	y = &model.X{Items: []*model.Item{{ID: 1, V: 1}, {ID: 2}, {ID: 2}}}
+	fmt.Println(model.EqualX(x, y), len(model.DiffX(x, y)))
+}
+`,
+}
+
+// TestKeyedRuntime tests that the elements of keyed slices with the same key are compared in order,
+// so a slice with duplicate keys is equal to its copy, and a key found a different number of times makes the slices different.
+func TestKeyedRuntime(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skip test that writes to disk")
+	}
+	out := runModule(t, keyedIn, "X", Options{Clone: true, Diff: true})
+	if expected := "true 0\ntrue 0\nfalse 2\nfalse 2\n"; out != expected {
+		t.Errorf("expected: \n%s, found: \n%s", expected, out)
+	}
+}
+
+// locksIn has types with locks in slices, which the generated code must not copy, and a program comparing their values
+var locksIn = map[string]string{
+	"model/model.go": `package model
+
+import "sync"
+
+type Item struct {
+	ID int
+	mu sync.Mutex
+}
+
+type X struct {
+	Items  []Item    ` + "`" + `goequal:"key=ID"` + "`" + `
+	Set    []Item    ` + "`" + `goequal:"unordered"` + "`" + `
+	Groups [][1]Item
+}
+`,
+	"main.go": `package main
+
+import (
+	"fmt"
+
+	"test/model"
+)
+
+func main() {
+	x := &model.X{Items: make([]model.Item, 2), Set: make([]model.Item, 2), Groups: make([][1]model.Item, 1)}
+	x.Items[1].ID, x.Set[0].ID = 1, 1
+	y := model.CloneX(x)
+	fmt.Println(model.EqualX(x, y), len(model.DiffX(x, y)))
+	y.Set[0].ID, y.Set[1].ID = 0, 1
+	fmt.Println(model.EqualX(x, y), len(model.DiffX(x, y)))
+	y.Items[1].ID, y.Groups[0][0].ID = 2, 1
+	fmt.Println(model.EqualX(x, y), len(model.DiffX(x, y)))
+}
+`,
+}
+
+// TestLocksRuntime tests that the generated code compares values with locks in slices without copying them, as go vet checks.
+func TestLocksRuntime(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skip test that writes to disk")
+	}
+	out := runModule(t, locksIn, "X", Options{Diff: true, Hash: true, Clone: true})
+	if expected := "true 0\ntrue 0\nfalse 3\n"; out != expected {
+		t.Errorf("expected: \n%s, found: \n%s", expected, out)
+	}
+}
+
+// TestWriteFiles tests that files are written only when all of them can be written, leaving no temporary files.
+func TestWriteFiles(t *testing.T) {
+	root := t.TempDir()
+	written, missing := &pkg{name: "a", dir: filepath.Join(root, "a")}, &pkg{name: "b", dir: filepath.Join(root, "b")}
+	writeFiles(t, root, map[string]string{"a/goequal_A.go": "old"})
+	g := &Generator{}
+	g.writeFiles([]file{
+		{name: "goequal_A.go", codes: []*code{newCode("A", written)}},
+		{name: "goequal_B.go", codes: []*code{newCode("B", missing)}},
+	})
+	if len(g.errs) != 1 {
+		t.Errorf("expected an error for the file in the missing dir, found: %v", g.errs)
+	}
+	entries, err := os.ReadDir(written.dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("expected only the old file, found %d files", len(entries))
+	}
+	if content, err := ioutil.ReadFile(filepath.Join(written.dir, "goequal_A.go")); err != nil || string(content) != "old" {
+		t.Errorf("expected the old file, found: %s %v", content, err)
+	}
+	g = &Generator{}
+	g.writeFiles([]file{{name: "goequal_A.go", codes: []*code{newCode("A", written)}}})
+	if content, err := ioutil.ReadFile(filepath.Join(written.dir, "goequal_A.go")); err != nil || !strings.HasPrefix(string(content), string(header)) {
+		t.Errorf("expected the new file, found: %s %v %v", content, err, g.errs)
+	}
+	invalid := newCode("C", written)
+	invalid.code = "func ("
+	g = &Generator{}
+	g.writeFiles([]file{{name: "goequal_C.go", codes: []*code{invalid}}})
+	if len(g.errs) != 1 || !strings.Contains(g.errs[0].Msg, "invalid Go generated") {
+		t.Errorf("expected an error for the invalid code, found: %v", g.errs)
+	}
+	if _, err := os.Stat(filepath.Join(written.dir, "goequal_C.go")); !os.IsNotExist(err) {
+		t.Errorf("expected no file for the invalid code, found: %v", err)
+	}
+}
+
+// runModule writes the files in a module named test, using this repository, generates the functions of the types typeName
+// of its package model, checks the module with go vet and returns the output of running its main package.
+func runModule(t *testing.T, in map[string]string, typeName string, options Options) string {
+	repo, err := filepath.Abs("..")
+	if err != nil {
+		t.Fatal(err)
+	}
+	goSum, err := ioutil.ReadFile(filepath.Join(repo, "go.sum"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	root := t.TempDir()
+	files := map[string]string{
+		"go.mod": "module test\n\ngo 1.24\n\nrequire github.com/gadumitrachioaiei/goequal v0.0.0\n\nreplace github.com/gadumitrachioaiei/goequal => " + repo + "\n",
+		"go.sum": string(goSum),
+	}
+	for name, content := range in {
+		files[name] = content
+	}
+	writeFiles(t, root, files)
+	t.Chdir(root)
+	if err := NewGenerator("./model", typeName, false, nil, options).Generate(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	cmd := exec.Command("go", "vet", "./...")
+	cmd.Env = append(os.Environ(), "GOFLAGS=-mod=mod", "GOPROXY=off")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("Error checking program: %s %s", err, out)
+	}
+	cmd = exec.Command("go", "run", ".")
+	cmd.Env = append(os.Environ(), "GOFLAGS=-mod=mod", "GOPROXY=off")
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("Error running program: %s %s", err, out)
+	}
+	return string(out)
+}
+
+// writeFiles writes the files, by their paths relative to root, creating their directories.
+func writeFiles(t *testing.T, root string, files map[string]string) {
+	for name, content := range files {
+		filePath := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(filePath), 0750); err != nil {
+			t.Fatalf("Can not create dir: %s %s", filePath, err)
+		}
+		if err := ioutil.WriteFile(filePath, []byte(content), 0640); err != nil {
+			t.Fatalf("Can not create file:%s %s", filePath, err)
 		}
 	}
 }
 
-func assertDisk(t *testing.T, g *Generator, test GoldenComplex) bool {
+func assertDisk(t *testing.T, pkgPaths []string, test GoldenComplex) bool {
 	success := true
 	for expectedType, expectedCode := range test.output {
-		pkgPath := filepath.Join(GOPATH, "src", expectedType.pkgPath)
+		pkgPath := pkgPaths[0]
+		if expectedType.pkgPath != test.pkgPath {
+			pkgPath = filepath.Join(pkgPaths[0], expectedType.pkgPath)
+		}
 		expectedFile := filepath.Join(pkgPath, fmt.Sprintf("goequal_%s.go", expectedType.name))
 		if content, err := ioutil.ReadFile(expectedFile); err != nil {
 			t.Errorf("%s", err)
@@ -290,8 +1042,10 @@ func assertDisk(t *testing.T, g *Generator, test GoldenComplex) bool {
 // TestEquality tests generated function by calling on specific instances
 func TestEquality(t *testing.T) {
 	t.Skip("Skip test that writes to disk")
-	g := NewGenerator("github.com/gadumitrachioaiei/goequal/equal/testdata", "X", false, nil)
-	g.Generate()
+	g := NewGenerator("github.com/gadumitrachioaiei/goequal/equal/testdata", "X", false, nil, Options{})
+	if err := g.Generate(); err != nil {
+		t.Fatal(err)
+	}
 	t1, t2 := &testdata.X{}, &testdata.X{}
 	if !testdata.EqualX(t1, t2) {
 		t.Fatal("Instances should be equal")
@@ -331,17 +1085,17 @@ func TestEquality(t *testing.T) {
 			t1.F11 = []map[int]*[]int{map[int]*[]int{1: &[]int{1, 2, 3}}}
 			t2.F11 = []map[int]*[]int{map[int]*[]int{1: &[]int{1, 3, 3}}}
 		case 12:
-			t1.F12 = testdata.Y{1}
-			t2.F12 = testdata.Y{2}
+			t1.F12 = testdata.Y{F1: 1}
+			t2.F12 = testdata.Y{F1: 2}
 		case 13:
-			t1.F13 = []*testdata.Y{&testdata.Y{1}, &testdata.Y{1}}
-			t2.F13 = []*testdata.Y{&testdata.Y{2}, &testdata.Y{1}}
+			t1.F13 = []*testdata.Y{&testdata.Y{F1: 1}, &testdata.Y{F1: 1}}
+			t2.F13 = []*testdata.Y{&testdata.Y{F1: 2}, &testdata.Y{F1: 1}}
 		case 14:
-			t1.F14 = map[testdata.Y]int{testdata.Y{1}: 1, testdata.Y{2}: 2}
-			t2.F14 = map[testdata.Y]int{testdata.Y{1}: 1, testdata.Y{2}: 3}
+			t1.F14 = map[testdata.Y]int{testdata.Y{F1: 1}: 1, testdata.Y{F1: 2}: 2}
+			t2.F14 = map[testdata.Y]int{testdata.Y{F1: 1}: 1, testdata.Y{F1: 2}: 3}
 		case 15:
-			t1.F15 = map[int]*testdata.Y{1: &testdata.Y{1}, 2: &testdata.Y{2}}
-			t2.F15 = map[int]*testdata.Y{1: &testdata.Y{1}, 2: &testdata.Y{3}}
+			t1.F15 = map[int]*testdata.Y{1: &testdata.Y{F1: 1}, 2: &testdata.Y{F1: 2}}
+			t2.F15 = map[int]*testdata.Y{1: &testdata.Y{F1: 1}, 2: &testdata.Y{F1: 3}}
 		case 16:
 			t1.F16 = [][]int{[]int{1, 2, 3}, []int{1, 2, 3}}
 			t2.F16 = [][]int{[]int{1, 2, 3}, []int{1, 2, 2}}
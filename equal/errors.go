@@ -0,0 +1,85 @@
+package equal
+
+import (
+	"errors"
+	"fmt"
+	"go/token"
+	"strings"
+)
+
+// Error describes a problem found while generating code.
+// Pos is the position of the offending type or field, it is not valid if the problem has no position.
+type Error struct {
+	Pos token.Position
+	Msg string
+}
+
+func (e *Error) Error() string {
+	if e.Pos.IsValid() {
+		return fmt.Sprintf("%s: %s", e.Pos, e.Msg)
+	}
+	return e.Msg
+}
+
+// Errors contains all the problems found during a generation run.
+type Errors []*Error
+
+func (e Errors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "\n")
+}
+
+// Unwrap returns the errors in the list, so they can be inspected with errors.Is and errors.As.
+func (e Errors) Unwrap() []error {
+	errs := make([]error, len(e))
+	for i, err := range e {
+		errs[i] = err
+	}
+	return errs
+}
+
+// add appends err to the list, flattening it if it is itself a list.
+// Problems already in the list are not added again, as the same type can be reached many times.
+func (e *Errors) add(err error) {
+	var list Errors
+	var single *Error
+	switch {
+	case errors.As(err, &list):
+	case errors.As(err, &single):
+		list = Errors{single}
+	default:
+		list = Errors{&Error{Msg: err.Error()}}
+	}
+	for _, newErr := range list {
+		if !e.contains(newErr) {
+			*e = append(*e, newErr)
+		}
+	}
+}
+
+// contains returns true if the list has a problem with the same position and message.
+func (e Errors) contains(err *Error) bool {
+	for _, old := range e {
+		if old.Pos == err.Pos && old.Msg == err.Msg {
+			return true
+		}
+	}
+	return false
+}
+
+// err returns the list as an error, or nil if it is empty.
+func (e Errors) err() error {
+	if len(e) == 0 {
+		return nil
+	}
+	return e
+}
+
+// internalError is raised by helpers that are only given input built by the generator itself.
+// Generate recovers it, as any other panic, and reports it as an error.
+type internalError struct {
+	msg string
+}
@@ -0,0 +1,214 @@
+package equal
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"go/types"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// packageFileName is the name of the file with the functions of a package, when they are written in a single file.
+const packageFileName = "goequal_equal.go"
+
+// file is a generated file: its name and the code of the types it has, all of the same package.
+type file struct {
+	name  string
+	codes []*code
+}
+
+// getFiles returns the files to be written: a file per type, in the order the types were generated,
+// or with SingleFile a file per package, in the order the packages were reached, with the types sorted by name.
+func (g *Generator) getFiles() []file {
+	var files []file
+	if !g.options.SingleFile {
+		for _, myType := range g.equalsOrder {
+			c := g.equals[myType]
+			files = append(files, file{name: fmt.Sprintf("goequal_%s.go", c.typeName), codes: []*code{c}})
+		}
+		return files
+	}
+	byPkg := make(map[string]int)
+	for _, myType := range g.equalsOrder {
+		i, ok := byPkg[myType.pkgPath]
+		if !ok {
+			i = len(files)
+			byPkg[myType.pkgPath] = i
+			files = append(files, file{name: packageFileName})
+		}
+		files[i].codes = append(files[i].codes, g.equals[myType])
+	}
+	for _, f := range files {
+		sort.Slice(f.codes, func(i, j int) bool { return f.codes[i].typeName < f.codes[j].typeName })
+	}
+	return files
+}
+
+// writeFiles writes the files, each to a temporary file in its directory, and renames the temporary files when all of them are written,
+// so a file which can not be written leaves every file as it was. A rename which fails leaves the files renamed before it written.
+func (g *Generator) writeFiles(files []file) {
+	var paths, temps []string
+	for _, file := range files {
+		path, content, err := serialize(file.name, file.codes)
+		var temp string
+		if err != nil {
+			g.formatError(file.codes, err)
+		} else if temp, err = writeTemp(path, content); err != nil {
+			g.errs = append(g.errs, &Error{Msg: fmt.Sprintf("Can not save file:%s: %s", path, err)})
+		}
+		if err != nil {
+			for _, temp := range temps {
+				os.Remove(temp)
+			}
+			return
+		}
+		paths, temps = append(paths, path), append(temps, temp)
+	}
+	for i, temp := range temps {
+		if err := os.Rename(temp, paths[i]); err != nil {
+			g.errs = append(g.errs, &Error{Msg: fmt.Sprintf("Can not save file:%s: %s", paths[i], err)})
+			os.Remove(temp)
+		}
+	}
+}
+
+// formatError reports the code of a file which is not valid Go, at the position of its first type, so the file is not written.
+func (g *Generator) formatError(codes []*code, err error) {
+	var obj types.Object
+	if c := codes[0]; c.pkg.types != nil {
+		obj = c.pkg.types.Scope().Lookup(c.typeName)
+	}
+	g.errorf(obj, "%s", err)
+}
+
+// writeTemp writes content to a new temporary file in the directory of path, hidden from the go tool, and returns its path.
+func writeTemp(path string, content []byte) (string, error) {
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return "", err
+	}
+	_, err = f.Write(content)
+	if err == nil {
+		err = f.Chmod(0644)
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), nil
+}
+
+// removeTypeFiles removes the files generated by us for each of the types of codes, in a file per type,
+// as they have the functions written now in the file of their package.
+func (g *Generator) removeTypeFiles(codes []*code) {
+	for _, c := range codes {
+		fileName := fmt.Sprintf("goequal_%s.go", c.typeName)
+		if fileName == packageFileName {
+			continue
+		}
+		path := filepath.Join(c.pkg.dir, fileName)
+		generated, err := isGenerated(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err == nil && generated {
+			err = os.Remove(path)
+		}
+		if err != nil {
+			g.errs = append(g.errs, &Error{Msg: fmt.Sprintf("Can not remove file:%s: %s", path, err)})
+		}
+	}
+}
+
+// keepPackageFiles generates again the types listed in the files of the packages written now, generated before with SingleFile,
+// so their functions are kept, whether the file is written again or replaced by a file per type.
+// Instances and helpers of types of other modules are generated again by the types using them, and with the Out option
+// types can not be generated again by their names, so a file listing a type which is not generated again is reported.
+func (g *Generator) keepPackageFiles() {
+	checked := make(map[string]bool)
+	for i := 0; i < len(g.equalsOrder); i++ {
+		pkgPath := g.equalsOrder[i].pkgPath
+		if checked[pkgPath] {
+			continue
+		}
+		checked[pkgPath] = true
+		path := filepath.Join(g.defs[pkgPath].dir, packageFileName)
+		typeNames, err := readGeneratedTypes(path)
+		if err != nil {
+			g.errs = append(g.errs, &Error{Msg: fmt.Sprintf("Can not read file:%s: %s", path, err)})
+			continue
+		}
+		for _, typeName := range typeNames {
+			myType := Type{name: typeName, pkgPath: pkgPath}
+			if g.equals[myType] != nil || g.options.Out != "" {
+				continue
+			}
+			if obj, err := g.findObj(myType); err == nil {
+				g.parseTypeDef(myType, obj)
+			}
+		}
+		var missing []string
+		for _, typeName := range typeNames {
+			if g.equals[Type{name: typeName, pkgPath: pkgPath}] == nil {
+				missing = append(missing, typeName)
+			}
+		}
+		if len(missing) > 0 {
+			g.errs = append(g.errs, &Error{Msg: fmt.Sprintf("file:%s has the functions of %s, which are not generated now, generate them too or remove the file",
+				path, strings.Join(missing, ", "))})
+		}
+	}
+}
+
+// removePackageFiles removes the files generated by us with SingleFile in the packages of codes,
+// as their functions are written now in a file per type.
+func (g *Generator) removePackageFiles(codes []*code) {
+	removed := make(map[string]bool)
+	for _, c := range codes {
+		path := filepath.Join(c.pkg.dir, packageFileName)
+		if removed[path] {
+			continue
+		}
+		removed[path] = true
+		generated, err := isGenerated(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err == nil && generated {
+			err = os.Remove(path)
+		}
+		if err != nil {
+			g.errs = append(g.errs, &Error{Msg: fmt.Sprintf("Can not remove file:%s: %s", path, err)})
+		}
+	}
+}
+
+// readGeneratedTypes returns the types listed in the header of a file generated by us, e.g.: Line, Order.
+// It returns no types if the file does not exist or it was not generated by us.
+func readGeneratedTypes(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	line, err := bufio.NewReader(f).ReadBytes('\n')
+	if err != nil && len(line) == 0 {
+		return nil, nil
+	}
+	if !bytes.HasPrefix(line, header) {
+		return nil, nil
+	}
+	// the header is: // Code generated by goequal for type: X; DO NOT EDIT, or for types: A, B; DO NOT EDIT
+	list := strings.TrimPrefix(strings.TrimPrefix(string(line[len(header):]), "s"), ": ")
+	list, _, _ = strings.Cut(list, ";")
+	return strings.Split(list, ", "), nil
+}
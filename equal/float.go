@@ -0,0 +1,78 @@
+package equal
+
+import (
+	"hash/maphash"
+	"math"
+	"unsafe"
+)
+
+// Float is the constraint of the floating-point types compared by generated functions.
+type Float interface {
+	~float32 | ~float64
+}
+
+// BothNaN returns true if a and b are both NaN.
+// Generated functions use it when NaN values are equal to each other.
+func BothNaN[F Float](a, b F) bool {
+	return a != a && b != b
+}
+
+// AbsEqual returns true if a and b differ by at most tolerance.
+func AbsEqual[F Float](a, b F, tolerance float64) bool {
+	if a == b {
+		return true
+	}
+	return math.Abs(float64(a)-float64(b)) <= tolerance
+}
+
+// RelEqual returns true if a and b differ by at most tolerance times the larger of their absolute values.
+func RelEqual[F Float](a, b F, tolerance float64) bool {
+	if a == b {
+		return true
+	}
+	x, y := float64(a), float64(b)
+	return math.Abs(x-y) <= tolerance*math.Max(math.Abs(x), math.Abs(y))
+}
+
+// ULPsEqual returns true if there are at most ulps representable values of type F between a and b.
+// Zero and negative zero are the same value, NaN is not equal to any value.
+func ULPsEqual[F Float](a, b F, ulps uint64) bool {
+	if a == b {
+		return true
+	}
+	if a != a || b != b {
+		return false
+	}
+	x, y := ordered(a), ordered(b)
+	if x < y {
+		x, y = y, x
+	}
+	return x-y <= ulps
+}
+
+// ordered maps v to an unsigned integer, so that consecutive floating-point values are mapped to consecutive integers.
+func ordered[F Float](v F) uint64 {
+	if unsafe.Sizeof(v) == 4 {
+		bits := math.Float32bits(float32(v))
+		if bits&(1<<31) != 0 {
+			// negative values are ordered backwards
+			return uint64(1<<31 - bits&(1<<31-1))
+		}
+		return uint64(bits) + 1<<31
+	}
+	bits := math.Float64bits(float64(v))
+	if bits&(1<<63) != 0 {
+		return 1<<63 - bits&(1<<63-1)
+	}
+	return bits + 1<<63
+}
+
+// HashFloat writes v to h, writing the same bytes for every NaN.
+// Generated Hash functions use it when NaN values are equal to each other, as maphash hashes every NaN differently.
+func HashFloat[F Float](h *maphash.Hash, v F) {
+	if v != v {
+		h.WriteByte(0)
+		return
+	}
+	maphash.WriteComparable(h, v)
+}
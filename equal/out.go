@@ -0,0 +1,190 @@
+package equal
+
+import (
+	"fmt"
+	"go/types"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// loadOut creates the output package, where every function is generated with the Out option, and returns its import path.
+// Out can be a directory, e.g.: ./internal/equality, or an import path, of the main module; the package may not exist yet.
+// An output package given as input is type checked from its code; test purposes only.
+func (g *Generator) loadOut(rootPath string) (string, error) {
+	out := g.options.Out
+	if _, isTest := g.input[out]; isTest {
+		return out, g.getPkg(out).checked()
+	}
+	module := g.getMainModule(rootPath)
+	if module == nil {
+		return "", fmt.Errorf("output package %s: there is no main module", out)
+	}
+	var dir, pkgPath string
+	if filepath.IsAbs(out) || out == "." || out == ".." || strings.HasPrefix(out, "./") || strings.HasPrefix(out, "../") {
+		var err error
+		if dir, err = filepath.Abs(out); err != nil {
+			return "", fmt.Errorf("output package %s: %s", out, err)
+		}
+		rel, err := filepath.Rel(module.Dir, dir)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return "", fmt.Errorf("output package %s is not in the main module %s", out, module.Path)
+		}
+		pkgPath = path.Join(module.Path, filepath.ToSlash(rel))
+	} else {
+		if out != module.Path && !strings.HasPrefix(out, module.Path+"/") {
+			return "", fmt.Errorf("output package %s is not in the main module %s", out, module.Path)
+		}
+		pkgPath = out
+		dir = filepath.Join(module.Dir, filepath.FromSlash(strings.TrimPrefix(out, module.Path)))
+	}
+	// the name of an existing package is kept, a new package is named after its directory
+	name := strings.NewReplacer("-", "_", ".", "_").Replace(path.Base(pkgPath))
+	if pkgs, err := packages.Load(&packages.Config{Mode: packages.NeedName, Dir: module.Dir}, pkgPath); err == nil && len(pkgs) == 1 && pkgs[0].Name != "" {
+		name = pkgs[0].Name
+	}
+	g.defs[pkgPath] = &pkg{name: name, path: pkgPath, dir: dir, imports: make(map[string]string)}
+	return pkgPath, nil
+}
+
+// getMainModule returns the main module, the module of the current directory, found among the modules of the loaded packages.
+func (g *Generator) getMainModule(rootPath string) *packages.Module {
+	if root := g.loaded[rootPath]; root != nil && root.Module != nil && root.Module.Main {
+		return root.Module
+	}
+	for _, loaded := range g.loaded {
+		if loaded.Module != nil && loaded.Module.Main {
+			return loaded.Module
+		}
+	}
+	return nil
+}
+
+// getOutFuncName generates in the output package, if it was not generated yet, the function of the named type and returns its name.
+func (g *Generator) getOutFuncName(typ *types.Named) (string, bool) {
+	return g.getLocalFuncName(g.outPkgPath, typ, false)
+}
+
+// unreferable returns the first type name referred by typ that can not be referred from the package pkgPath, as it is not exported.
+// Type arguments and the elements of composite types are checked too, e.g.: Entry[[]item] returns item. It returns nil if there is none.
+func unreferable(typ types.Type, pkgPath string) *types.TypeName {
+	switch t := types.Unalias(typ).(type) {
+	case *types.Named:
+		if obj := t.Obj(); !obj.Exported() && obj.Pkg() != nil && obj.Pkg().Path() != pkgPath {
+			return obj
+		}
+		for i := 0; i < t.TypeArgs().Len(); i++ {
+			if obj := unreferable(t.TypeArgs().At(i), pkgPath); obj != nil {
+				return obj
+			}
+		}
+	case *types.Pointer:
+		return unreferable(t.Elem(), pkgPath)
+	case *types.Slice:
+		return unreferable(t.Elem(), pkgPath)
+	case *types.Array:
+		return unreferable(t.Elem(), pkgPath)
+	case *types.Chan:
+		return unreferable(t.Elem(), pkgPath)
+	case *types.Map:
+		if obj := unreferable(t.Key(), pkgPath); obj != nil {
+			return obj
+		}
+		return unreferable(t.Elem(), pkgPath)
+	}
+	return nil
+}
+
+// canImport returns true if the package pkgPath can import the package importPath:
+// an internal package can be imported only by the packages of the tree rooted at the parent of its internal directory.
+func canImport(pkgPath, importPath string) bool {
+	elems := strings.Split(importPath, "/")
+	for i := len(elems) - 1; i >= 0; i-- {
+		if elems[i] != "internal" {
+			continue
+		}
+		parent := strings.Join(elems[:i], "/")
+		return parent == "" || pkgPath == parent || strings.HasPrefix(pkgPath, parent+"/")
+	}
+	return true
+}
+
+// checkExported handles the fields of the named type of another package that are not exported, as they can not be compared outside their package
+// by the function of myType: they are reported, or with the Unexported option skipped or compared with the whole value by reflect.DeepEqual.
+func (g *Generator) checkExported(myType Type, named *types.Named) {
+	if named.Obj().Pkg().Path() == myType.pkgPath {
+		return
+	}
+	fields := g.getUnexported(named.Underlying())
+	if len(fields) == 0 {
+		return
+	}
+	switch g.options.Unexported {
+	case UnexportedSkip:
+		// the fields are skipped by parseStruct
+	case UnexportedReflect:
+		g.reflected[myType] = true
+	default:
+		for _, field := range fields {
+			g.errorf(field, "field %s of %s is not exported, it can not be compared outside package %s", field.Name(), named, named.Obj().Pkg().Path())
+		}
+	}
+}
+
+// getUnexported returns the fields of typ, the underlying type of a named type, that are not exported. Fields of anonymous structs are returned too,
+// fields of other named types are checked when their functions are generated. Skipped fields are not compared, so they are not returned.
+func (g *Generator) getUnexported(typ types.Type) []*types.Var {
+	var fields []*types.Var
+	switch t := types.Unalias(typ).(type) {
+	case *types.Struct:
+		for i := 0; i < t.NumFields(); i++ {
+			field := t.Field(i)
+			if g.getTag(field).skip {
+				continue
+			}
+			if !field.Exported() {
+				fields = append(fields, field)
+				continue
+			}
+			fields = append(fields, g.getUnexported(field.Type())...)
+		}
+	case *types.Pointer:
+		return g.getUnexported(t.Elem())
+	case *types.Slice:
+		return g.getUnexported(t.Elem())
+	case *types.Array:
+		return g.getUnexported(t.Elem())
+	case *types.Map:
+		return append(g.getUnexported(t.Key()), g.getUnexported(t.Elem())...)
+	}
+	return fields
+}
+
+// isSkippedUnexported returns true if field is not exported and it is compared outside its package, with the UnexportedSkip strategy.
+func (g *Generator) isSkippedUnexported(field *types.Var) bool {
+	current := g.usedTypes[len(g.usedTypes)-1]
+	return g.options.Unexported == UnexportedSkip && !field.Exported() && field.Pkg().Path() != current.pkgPath
+}
+
+// getOutImportName returns the name the output package imports the package pkgPath with, named name.
+// Two imported packages can have the same name, e.g.: billing/model and shipping/model, so the second one is imported as model2.
+func (g *Generator) getOutImportName(pkgPath, name string) string {
+	if importName, ok := g.outImports[pkgPath]; ok {
+		return importName
+	}
+	taken := make(map[string]bool)
+	for _, importName := range g.outImports {
+		taken[importName] = true
+	}
+	importName := name
+	for i := 2; taken[importName]; i++ {
+		importName = fmt.Sprintf("%s%d", name, i)
+	}
+	g.outImports[pkgPath] = importName
+	if importName != name {
+		g.defs[g.outPkgPath].imports[pkgPath] = importName
+	}
+	return importName
+}
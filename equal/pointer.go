@@ -0,0 +1,10 @@
+package equal
+
+// OrZero returns p, or a pointer to a new zero value if p is nil.
+// Generated functions use it in semantic mode, where a nil pointer is equal to a pointer to a zero value.
+func OrZero[T any](p *T) *T {
+	if p == nil {
+		return new(T)
+	}
+	return p
+}
@@ -0,0 +1,61 @@
+package equal
+
+import (
+	"reflect"
+	"sync"
+)
+
+// Equaler is implemented by values that compare themselves to other values,
+// e.g.: values of types from plugins, which generated functions can't know about.
+type Equaler interface {
+	Equal(other any) bool
+}
+
+// registry maps dynamic types of interface values to the functions comparing them.
+var registry sync.Map // map[reflect.Type]func(a, b any) bool
+
+// Register registers eq as the function comparing interface values holding values of type T or pointers to them.
+// Generated packages register the functions of their struct types in init functions, e.g.: equal.Register(EqualOrder).
+func Register[T any](eq func(t1, t2 *T) bool) {
+	registry.Store(reflect.TypeFor[T](), func(a, b any) bool {
+		t1, t2 := a.(T), b.(T)
+		return eq(&t1, &t2)
+	})
+	registry.Store(reflect.TypeFor[*T](), func(a, b any) bool {
+		return eq(a.(*T), b.(*T))
+	})
+}
+
+// RegisterValue registers eq as the function comparing interface values holding values of type T.
+// Generated packages register the functions of their other types in init functions, e.g.: equal.RegisterValue(EqualTags).
+func RegisterValue[T any](eq func(t1, t2 T) bool) {
+	registry.Store(reflect.TypeFor[T](), func(a, b any) bool {
+		return eq(a.(T), b.(T))
+	})
+}
+
+// Interface returns true if the interface values a and b are equal.
+// Values of different dynamic types are not equal. Values of the same type are compared by the Equal method of a, if it is an Equaler,
+// with the registered function of the type, if there is one, or with reflect.DeepEqual. The Equal method is not called for nil pointers,
+// which are equal only to nil pointers. Generated functions compare interface values with it.
+func Interface(a, b any) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	typ := reflect.TypeOf(a)
+	if typ != reflect.TypeOf(b) {
+		return false
+	}
+	if equaler, ok := a.(Equaler); ok {
+		if typ.Kind() == reflect.Pointer {
+			if nil1, nil2 := reflect.ValueOf(a).IsNil(), reflect.ValueOf(b).IsNil(); nil1 || nil2 {
+				return nil1 && nil2
+			}
+		}
+		return equaler.Equal(b)
+	}
+	if eq, ok := registry.Load(typ); ok {
+		return eq.(func(a, b any) bool)(a, b)
+	}
+	return reflect.DeepEqual(a, b)
+}
@@ -0,0 +1,125 @@
+package equal
+
+import (
+	"fmt"
+	"go/token"
+	"go/types"
+	"regexp"
+	"strings"
+)
+
+// getRootTypes returns the types of the packages pkgPaths whose functions are generated, package by package, in the order they are given:
+// the types named by typeName, separated by commas, e.g.: Order,Line, and the types whose names match the regular expressions among them,
+// e.g.: ^Event.*, sorted by name. With the All option every named struct, map and slice type declared in the packages is a root type too,
+// and with the AllAnnotated option every type declared with the generate directive.
+// Types matched by a regular expression or by All are left out if they are ignored, or if they are not exported, with the Out option.
+// It reports the names that are not found, the regular expressions that are not valid or match no type.
+// Types can be named only in a single package, as a name can be declared in many packages.
+func (g *Generator) getRootTypes(pkgPaths []string) []types.Object {
+	var names []string
+	var patterns []*regexp.Regexp
+	for _, name := range strings.Split(g.typeName, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if token.IsIdentifier(name) {
+			names = append(names, name)
+			patterns = append(patterns, nil)
+			continue
+		}
+		pattern, err := regexp.Compile(name)
+		if err != nil {
+			g.errs.add(&Error{Msg: fmt.Sprintf("type pattern %s is not a valid regular expression: %s", name, err)})
+			continue
+		}
+		names = append(names, name)
+		patterns = append(patterns, pattern)
+	}
+	for i, name := range names {
+		if patterns[i] == nil && len(pkgPaths) > 1 {
+			g.errs.add(&Error{Msg: fmt.Sprintf("type %s can not be given by name for the %d packages of %s, use a regular expression", name, len(pkgPaths), g.pkgPath)})
+		}
+	}
+	if len(g.errs) > 0 {
+		return nil
+	}
+	var objs []types.Object
+	found := make(map[types.Object]bool)
+	add := func(obj types.Object) {
+		if !found[obj] {
+			found[obj] = true
+			objs = append(objs, obj)
+		}
+	}
+	matches := make([]int, len(names))
+	for _, pkgPath := range pkgPaths {
+		pkgObj := g.getPkg(pkgPath)
+		if err := pkgObj.checked(); err != nil {
+			g.errs.add(err)
+			continue
+		}
+		for i, name := range names {
+			if patterns[i] == nil {
+				obj, err := pkgObj.findObj(name)
+				if err != nil {
+					g.errs.add(err)
+					continue
+				}
+				add(obj)
+				matches[i]++
+				continue
+			}
+			pattern := patterns[i]
+			for _, obj := range g.getDeclaredTypes(pkgObj, func(obj *types.TypeName) bool { return pattern.MatchString(obj.Name()) }) {
+				add(obj)
+				matches[i]++
+			}
+		}
+		if g.options.All {
+			for _, obj := range g.getDeclaredTypes(pkgObj, isStructMapSlice) {
+				add(obj)
+			}
+		}
+		if g.options.AllAnnotated {
+			for _, obj := range g.getDeclaredTypes(pkgObj, func(obj *types.TypeName) bool { return pkgObj.annotated[obj.Pos()] }) {
+				add(obj)
+			}
+		}
+	}
+	for i, name := range names {
+		if patterns[i] != nil && matches[i] == 0 {
+			g.errs.add(&Error{Msg: fmt.Sprintf("no type matches %s in package %s", name, g.pkgPath)})
+		}
+	}
+	if len(objs) == 0 && len(g.errs) == 0 {
+		g.errs.add(&Error{Msg: fmt.Sprintf("no type to generate functions for in package %s", g.pkgPath)})
+	}
+	return objs
+}
+
+// getDeclaredTypes returns the named types declared at the package level of pkgObj, sorted by name, for which match returns true.
+// Aliases and ignored types are left out, and so are the types that are not exported, with the Out option.
+func (g *Generator) getDeclaredTypes(pkgObj *pkg, match func(obj *types.TypeName) bool) []types.Object {
+	var objs []types.Object
+	scope := pkgObj.types.Scope()
+	for _, name := range scope.Names() {
+		obj, ok := scope.Lookup(name).(*types.TypeName)
+		if !ok || obj.IsAlias() || g.isIgnored(obj) || (g.options.Out != "" && !obj.Exported()) {
+			continue
+		}
+		if _, ok := obj.Type().(*types.Named); ok && match(obj) {
+			objs = append(objs, obj)
+		}
+	}
+	return objs
+}
+
+// isStructMapSlice returns true if the type is a struct, a map or a slice type, the types covered by the All option.
+func isStructMapSlice(obj *types.TypeName) bool {
+	switch obj.Type().Underlying().(type) {
+	case *types.Struct, *types.Map, *types.Slice:
+		return true
+	}
+	return false
+}
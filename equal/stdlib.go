@@ -0,0 +1,277 @@
+package equal
+
+import (
+	"fmt"
+	"go/token"
+	"go/types"
+)
+
+// stdlibComparison tells how values of a named type from standard library are compared,
+// as their fields are not part of their meaning, e.g.: two big.Int values are equal if Cmp says so.
+// The functions get go expressions of the values, which are addressable.
+type stdlibComparison struct {
+	different func(g *Generator, value1, value2 string) string // condition of two values being different, nil if the type has no sensible equality
+	formatted func(g *Generator, value string) string          // value reported by Diff functions, nil for the value itself
+	hash      func(g *Generator, hasher, value string) string  // code hashing a value, nil if values are not hashed
+	clone     func(g *Generator, dst, src string) string       // code copying a value, nil for an assignment
+	skip      bool                                             // values are not compared, hashed nor copied
+}
+
+// stdlibComparisons are the comparisons of named types from standard library, by package path and name.
+// Types with an Equal method, e.g.: time.Time, are compared by it, and named basic types, e.g.: time.Duration, are compared as basic values,
+// so they are not here. Other comparable struct and array types are compared with ==, the skipped types are ignored,
+// and the types left are reported, as they can not be compared.
+var stdlibComparisons = map[string]stdlibComparison{
+	"math/big.Int":                 {different: cmpDifferent, formatted: address, hash: stringHash, clone: setClone},
+	"math/big.Rat":                 {different: cmpDifferent, formatted: address, hash: stringHash, clone: setClone},
+	"math/big.Float":               {different: cmpDifferent, formatted: address, clone: setClone}, // 0 and -0 are equal, but their strings are not
+	"net/url.URL":                  {different: stringDifferent, formatted: address, hash: stringHash},
+	"net/url.Userinfo":             {different: stringDifferent, formatted: address, hash: stringHash},
+	"regexp.Regexp":                {different: stringDifferent, formatted: address, hash: stringHash},
+	"encoding/json.RawMessage":     jsonComparison,
+	"encoding/json/jsontext.Value": jsonComparison, // json.RawMessage is an alias of it with json v2
+	"database/sql.NullBool":        nullComparison("Bool"),
+	"database/sql.NullByte":        nullComparison("Byte"),
+	"database/sql.NullFloat64":     nullComparison("Float64"),
+	"database/sql.NullInt16":       nullComparison("Int16"),
+	"database/sql.NullInt32":       nullComparison("Int32"),
+	"database/sql.NullInt64":       nullComparison("Int64"),
+	"database/sql.NullString":      nullComparison("String"),
+	"database/sql.NullTime": {
+		different: func(g *Generator, value1, value2 string) string {
+			return fmt.Sprintf("%s.Valid != %s.Valid || %s.Valid && !%s.Time.Equal(%s.Time)", value1, value2, value1, value1, value2)
+		},
+		// the same instant in other locations is equal, so only the validity is hashed
+		hash: func(g *Generator, hasher, value string) string { return g.getWrite(hasher, value+".Valid") },
+	},
+	"container/list.List": {
+		different: func(g *Generator, value1, value2 string) string {
+			return fmt.Sprintf("!%s(&%s, &%s)", g.getReferenceUpdateImports(equalPkgPath, "ListEqual"), value1, value2)
+		},
+		formatted: func(g *Generator, value string) string {
+			return fmt.Sprintf("%s(&%s)", g.getReferenceUpdateImports(equalPkgPath, "ListValues"), value)
+		},
+		clone: func(g *Generator, dst, src string) string {
+			return fmt.Sprintf("%s(&%s, &%s)\n", g.getReferenceUpdateImports(equalPkgPath, "CloneList"), dst, src)
+		},
+	},
+	"sync.Map": {
+		different: func(g *Generator, value1, value2 string) string {
+			return fmt.Sprintf("!%s(&%s, &%s)", g.getReferenceUpdateImports(equalPkgPath, "SyncMapEqual"), value1, value2)
+		},
+		formatted: func(g *Generator, value string) string {
+			return fmt.Sprintf("%s(&%s)", g.getReferenceUpdateImports(equalPkgPath, "SyncMapValues"), value)
+		},
+		clone: func(g *Generator, dst, src string) string {
+			return fmt.Sprintf("%s(&%s, &%s)\n", g.getReferenceUpdateImports(equalPkgPath, "CloneSyncMap"), dst, src)
+		},
+	},
+	"sync/atomic.Bool":    atomicComparison,
+	"sync/atomic.Int32":   atomicComparison,
+	"sync/atomic.Int64":   atomicComparison,
+	"sync/atomic.Uint32":  atomicComparison,
+	"sync/atomic.Uint64":  atomicComparison,
+	"sync/atomic.Uintptr": atomicComparison,
+	"sync/atomic.Pointer": atomicComparison, // pointers are equal if they point to the same value
+	"sync/atomic.Value": {
+		different: func(g *Generator, value1, value2 string) string {
+			return fmt.Sprintf("!%s(%s.Load(), %s.Load())", g.getReferenceUpdateImports(equalPkgPath, "Interface"), value1, value2)
+		},
+		formatted: func(g *Generator, value string) string { return value + ".Load()" },
+		// a nil value can not be stored, so values never stored are left as they are
+		clone: func(g *Generator, dst, src string) string {
+			return fmt.Sprintf("if value := %s.Load(); value != nil {\n%s.Store(value)\n}\n", src, dst)
+		},
+	},
+	"sync.Cond":      lockComparison,
+	"sync.Mutex":     lockComparison,
+	"sync.Once":      lockComparison,
+	"sync.Pool":      lockComparison,
+	"sync.RWMutex":   lockComparison,
+	"sync.WaitGroup": lockComparison,
+}
+
+// lockComparison skips synchronization types: their state is not part of the value, and their values must not be copied,
+// so clones get zero values.
+var lockComparison = stdlibComparison{skip: true}
+
+// jsonComparison compares raw JSON messages byte by byte.
+var jsonComparison = stdlibComparison{
+	different: func(g *Generator, value1, value2 string) string {
+		return fmt.Sprintf("!%s(%s, %s)", g.getReferenceUpdateImports("bytes", "Equal"), value1, value2)
+	},
+	formatted: func(g *Generator, value string) string { return "string(" + value + ")" },
+	hash:      func(g *Generator, hasher, value string) string { return fmt.Sprintf("%s.Write(%s)\n", hasher, value) },
+	// nil and empty messages are copied as they are
+	clone: func(g *Generator, dst, src string) string {
+		return fmt.Sprintf("%s = append(%s[:0:0], %s...)\n", dst, src, src)
+	},
+}
+
+// atomicComparison compares the loaded values of atomic types.
+var atomicComparison = stdlibComparison{
+	different: func(g *Generator, value1, value2 string) string { return value1 + ".Load() != " + value2 + ".Load()" },
+	formatted: func(g *Generator, value string) string { return value + ".Load()" },
+	hash:      func(g *Generator, hasher, value string) string { return g.getWrite(hasher, value+".Load()") },
+	clone:     func(g *Generator, dst, src string) string { return fmt.Sprintf("%s.Store(%s.Load())\n", dst, src) },
+}
+
+// nullComparison compares sql null types by validity and by the value of field, which is ignored for null values.
+func nullComparison(field string) stdlibComparison {
+	return stdlibComparison{
+		different: func(g *Generator, value1, value2 string) string {
+			return fmt.Sprintf("%s.Valid != %s.Valid || %s.Valid && %s.%s != %s.%s", value1, value2, value1, value1, field, value2, field)
+		},
+		hash: func(g *Generator, hasher, value string) string {
+			return fmt.Sprintf("%sif %s.Valid {\n%s}\n", g.getWrite(hasher, value+".Valid"), value, g.getWrite(hasher, value+"."+field))
+		},
+	}
+}
+
+// cmpDifferent compares values with their Cmp method, which has pointer receivers and arguments.
+func cmpDifferent(g *Generator, value1, value2 string) string {
+	return fmt.Sprintf("%s.Cmp(&%s) != 0", value1, value2)
+}
+
+// setClone copies values with their Set method, as assignments would share their memory.
+func setClone(g *Generator, dst, src string) string {
+	return fmt.Sprintf("%s.Set(&%s)\n", dst, src)
+}
+
+// stringDifferent compares the canonical strings of values.
+func stringDifferent(g *Generator, value1, value2 string) string {
+	return fmt.Sprintf("%s.String() != %s.String()", value1, value2)
+}
+
+// stringHash hashes the canonical string of a value.
+func stringHash(g *Generator, hasher, value string) string {
+	return g.getWrite(hasher, value+".String()")
+}
+
+// address formats values by their address, as their String methods have pointer receivers.
+func address(g *Generator, value string) string {
+	return "&" + value
+}
+
+// valueComparison compares values of the other comparable struct and array types with ==, e.g.: netip.Addr.
+var valueComparison = stdlibComparison{
+	different: func(g *Generator, value1, value2 string) string { return value1 + " != " + value2 },
+	hash:      func(g *Generator, hasher, value string) string { return g.getWrite(hasher, value) },
+}
+
+// getStdlibComparison returns the comparison of a named type from standard library, from the catalog,
+// or comparing with == the comparable types which are not in the catalog and are not basic types, compared as basic values.
+// It returns false if the type is not declared in standard library, if it is skipped or if it has no comparison.
+func (g *Generator) getStdlibComparison(typ *types.Named) (stdlibComparison, bool) {
+	if typ.Obj().Pkg() == nil {
+		return stdlibComparison{}, false
+	}
+	comparison, ok := stdlibComparisons[typ.Obj().Pkg().Path()+"."+typ.Obj().Name()]
+	if ok && comparison.skip {
+		return stdlibComparison{}, false
+	}
+	if !ok {
+		switch typ.Underlying().(type) {
+		case *types.Struct, *types.Array:
+			if !types.Comparable(typ) {
+				return stdlibComparison{}, false
+			}
+			comparison = valueComparison
+		default:
+			return stdlibComparison{}, false
+		}
+	}
+	if isGOROOT, err := g.isGOROOT(typ.Obj()); err != nil || !isGOROOT {
+		return stdlibComparison{}, false
+	}
+	return comparison, true
+}
+
+// checkStdlib reports the named type from standard library which can not be compared, that typ, the type of obj, is or leads to,
+// so values of the type are not taken as equal silently.
+func (g *Generator) checkStdlib(obj types.Object, typ types.Type) {
+	var unknown *types.Named
+	contains(typ, func(typ types.Type) bool {
+		named, ok := types.Unalias(typ).(*types.Named)
+		if ok && g.isUnknownStdlib(named) {
+			unknown = named
+		}
+		return unknown != nil
+	})
+	if unknown == nil {
+		return
+	}
+	if _, ok := obj.(*types.Var); ok {
+		g.errorf(obj, "type %s of standard library has no known comparison, compare field %s with a goequal:\"func=...\" tag or skip it with goequal:\"-\"",
+			types.TypeString(unknown, nil), obj.Name())
+	} else {
+		g.errorf(obj, "type %s of standard library has no known comparison, ignore type %s with the %s directive",
+			types.TypeString(unknown, nil), obj.Name(), ignoreDirective)
+	}
+}
+
+// isUnknownStdlib returns true if typ is a named type from standard library which the generator can not compare:
+// it is not in the catalog, it has no Equal method, and it is not a basic, an interface or a comparable type.
+func (g *Generator) isUnknownStdlib(typ *types.Named) bool {
+	if isGOROOT, err := g.isGOROOT(typ.Obj()); err != nil || !isGOROOT || typ.Obj().Pkg() == nil {
+		return false
+	}
+	if _, ok := stdlibComparisons[typ.Obj().Pkg().Path()+"."+typ.Obj().Name()]; ok {
+		return false
+	}
+	if _, ok := g.getStdlibComparison(typ); ok || g.getEqualMethod(typ) != nil {
+		return false
+	}
+	switch typ.Underlying().(type) {
+	case *types.Basic, *types.Interface, *types.Chan, *types.Signature:
+		return false
+	}
+	return true
+}
+
+// parseStdlib generates code comparing two values of a named type from standard library with its comparison.
+func (g *Generator) parseStdlib(name string, comparison stdlibComparison, isType bool) string {
+	// types whose values are not hashed are ignored by Hash functions, so they are not parsed
+	if g.hash {
+		return comparison.hash(g, getHasher(name), getName(name, isType))
+	}
+	if g.clone {
+		src, dst := getCloneNames(name, isType)
+		if comparison.clone == nil {
+			return fmt.Sprintf("%s = %s\n", dst, src)
+		}
+		return comparison.clone(g, dst, src)
+	}
+	name1, name2 := getNames(name, isType)
+	value1, value2 := name1, name2
+	if comparison.formatted != nil {
+		value1, value2 = comparison.formatted(g, name1), comparison.formatted(g, name2)
+	}
+	return g.getCheck(comparison.different(g, name1, name2), g.getFailure(name, isType, "DifferentValues", value1, value2), "")
+}
+
+// locker is the method set of sync.Locker.
+var locker = types.NewInterfaceType([]*types.Func{
+	types.NewFunc(token.NoPos, nil, "Lock", types.NewSignatureType(nil, nil, nil, nil, nil, false)),
+	types.NewFunc(token.NoPos, nil, "Unlock", types.NewSignatureType(nil, nil, nil, nil, nil, false)),
+}, nil).Complete()
+
+// containsLock returns true if values of typ contain a lock, e.g.: a sync.Mutex, or the struct with a lock of an atomic type,
+// as go vet says: a struct whose pointers are lockers but whose values are not. Such values must not be copied,
+// so the generated code gets them by index and compares them by pointer.
+func containsLock(typ types.Type) bool {
+	switch t := typ.Underlying().(type) {
+	case *types.Array:
+		return containsLock(t.Elem())
+	case *types.Struct:
+		if types.Implements(types.NewPointer(typ), locker) && !types.Implements(typ, locker) {
+			return true
+		}
+		for i := 0; i < t.NumFields(); i++ {
+			if containsLock(t.Field(i).Type()) {
+				return true
+			}
+		}
+	}
+	return false
+}
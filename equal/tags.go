@@ -0,0 +1,397 @@
+package equal
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// ignoreDirective is the comment on a type declaration that makes every value of the type equal to any other.
+const ignoreDirective = "//goequal:ignore"
+
+// generateDirective is the comment on a type declaration that makes the type get its functions with the AllAnnotated option.
+const generateDirective = "//goequal:generate"
+
+// fieldTag holds the options given to a struct field with the goequal tag, e.g.: `goequal:"-"`.
+type fieldTag struct {
+	skip        bool   // the field is not compared
+	function    string // name of the function comparing the field, as given in the tag, e.g.: sameOrder or pkgpath.FuncName
+	funcPkgPath string // import path of the package declaring the function
+	funcName    string // name of the function in its package
+	unordered   bool   // the slice of the field is compared as a multiset
+	key         string // name of the field of the slice elements matching the elements of two slices, e.g.: ID
+	abs         string // absolute tolerance of the floating-point numbers of the field, as given in the tag, e.g.: 1e-9
+	rel         string // relative tolerance of the floating-point numbers of the field, e.g.: 1e-6
+	ulps        string // number of representable values allowed between the floating-point numbers of the field, e.g.: 4
+	semantic    bool   // a nil pointer of the field is equal to a pointer to a zero value
+}
+
+// hasTolerance returns true if the floating-point numbers of the field are equal within a tolerance.
+func (tag fieldTag) hasTolerance() bool {
+	return tag.abs != "" || tag.rel != "" || tag.ulps != ""
+}
+
+// parseTag parses the value of the goequal tag of a struct field.
+// The value is "-" or a comma separated list of options, e.g.: unordered, semantic, func=sameOrder, key=ID or abs=1e-9.
+// With key=ID, the elements of two slices with the same key are compared in their order, so a slice with duplicate keys
+// is equal to its copy, and a key found a different number of times in the two slices makes them different.
+func parseTag(value string) (fieldTag, error) {
+	var tag fieldTag
+	if value == "-" {
+		tag.skip = true
+		return tag, nil
+	}
+	for _, option := range strings.Split(value, ",") {
+		switch {
+		case option == "unordered":
+			tag.unordered = true
+		case option == "semantic":
+			tag.semantic = true
+		case strings.HasPrefix(option, "func="):
+			tag.function = strings.TrimPrefix(option, "func=")
+			if tag.function == "" {
+				return tag, fmt.Errorf("missing function name in goequal tag: %q", value)
+			}
+		case strings.HasPrefix(option, "key="):
+			tag.key = strings.TrimPrefix(option, "key=")
+			if tag.key == "" {
+				return tag, fmt.Errorf("missing key field name in goequal tag: %q", value)
+			}
+		case strings.HasPrefix(option, "abs="), strings.HasPrefix(option, "rel="):
+			name, tolerance, _ := strings.Cut(option, "=")
+			if v, err := strconv.ParseFloat(tolerance, 64); err != nil || v < 0 || math.IsInf(v, 0) {
+				return tag, fmt.Errorf("%s tolerance in goequal tag is not a non-negative number: %q", name, value)
+			}
+			if name == "abs" {
+				tag.abs = tolerance
+			} else {
+				tag.rel = tolerance
+			}
+		case strings.HasPrefix(option, "ulps="):
+			tag.ulps = strings.TrimPrefix(option, "ulps=")
+			if _, err := strconv.ParseUint(tag.ulps, 10, 64); err != nil {
+				return tag, fmt.Errorf("ulps tolerance in goequal tag is not a non-negative integer: %q", value)
+			}
+		default:
+			return tag, fmt.Errorf("unknown goequal tag: %q", option)
+		}
+	}
+	// every option decides on its own how the field is compared
+	var used []string
+	if tag.function != "" {
+		used = append(used, "func")
+	}
+	if tag.key != "" {
+		used = append(used, "key")
+	}
+	if tag.unordered {
+		used = append(used, "unordered")
+	}
+	// tolerances can be used together, two numbers are equal if they are within any of them,
+	// but not with unordered: elements are matched greedily, which needs an equality that is transitive, and tolerances are not
+	if tag.function != "" || tag.unordered {
+		for _, tolerance := range [][2]string{{"abs", tag.abs}, {"rel", tag.rel}, {"ulps", tag.ulps}} {
+			if tolerance[1] != "" {
+				used = append(used, tolerance[0])
+			}
+		}
+		if tag.semantic {
+			used = append(used, "semantic")
+		}
+	}
+	if len(used) > 1 {
+		return tag, fmt.Errorf("goequal tag options %s can not be used together: %q", strings.Join(used, " and "), value)
+	}
+	return tag, nil
+}
+
+// checkTag checks that the options of the tag can be used for field.
+func (p *pkg) checkTag(tag *fieldTag, typesPkg *types.Package, field types.Object) error {
+	if tag.function != "" {
+		return p.resolveFunc(tag, typesPkg, field)
+	}
+	if tag.hasTolerance() && !contains(field.Type(), isFloat) {
+		return fmt.Errorf("field %s of type %s has no floating-point numbers, it can not have a tolerance",
+			field.Name(), types.TypeString(field.Type(), types.RelativeTo(typesPkg)))
+	}
+	if tag.semantic && !contains(field.Type(), isPointerType) {
+		return fmt.Errorf("field %s of type %s has no pointers, it can not be semantic",
+			field.Name(), types.TypeString(field.Type(), types.RelativeTo(typesPkg)))
+	}
+	if !tag.unordered && tag.key == "" {
+		return nil
+	}
+	sliceType := findSlice(field.Type())
+	if sliceType == nil {
+		return fmt.Errorf("field %s of type %s is not a slice or a map of slices, it can not be compared regardless of order",
+			field.Name(), types.TypeString(field.Type(), types.RelativeTo(typesPkg)))
+	}
+	if tag.key != "" {
+		if _, err := keyField(sliceType, tag.key, typesPkg.Path()); err != nil {
+			return fmt.Errorf("field %s can not be matched by key: %s", field.Name(), err)
+		}
+	}
+	return nil
+}
+
+// findSlice returns the slice of typ: typ itself, or the slice a map or a pointer leads to.
+// It returns nil if typ has no slice.
+func findSlice(typ types.Type) *types.Slice {
+	switch t := typ.Underlying().(type) {
+	case *types.Slice:
+		return t
+	case *types.Map:
+		return findSlice(t.Elem())
+	case *types.Pointer:
+		return findSlice(t.Elem())
+	}
+	return nil
+}
+
+// contains returns true if typ matches, or if it is an array, slice, map or pointer leading to a type that matches.
+// The fields of structs are compared by their own functions, so they are not searched.
+func contains(typ types.Type, match func(types.Type) bool) bool {
+	if match(typ) {
+		return true
+	}
+	switch t := typ.Underlying().(type) {
+	case *types.Array:
+		return contains(t.Elem(), match)
+	case *types.Slice:
+		return contains(t.Elem(), match)
+	case *types.Map:
+		return contains(t.Elem(), match)
+	case *types.Pointer:
+		return contains(t.Elem(), match)
+	}
+	return false
+}
+
+// isFloat returns true if typ is a floating-point or complex number.
+func isFloat(typ types.Type) bool {
+	basic, ok := typ.Underlying().(*types.Basic)
+	return ok && basic.Info()&(types.IsFloat|types.IsComplex) != 0
+}
+
+// isPointerType returns true if typ is a pointer.
+func isPointerType(typ types.Type) bool {
+	_, ok := typ.Underlying().(*types.Pointer)
+	return ok
+}
+
+// keyField returns the field named key of the elements of sliceType, which are structs or pointers to structs.
+// The field must be comparable, so it can be a map key, and accessible from the package with import path pkgPath.
+func keyField(sliceType *types.Slice, key string, pkgPath string) (*types.Var, error) {
+	elem := sliceType.Elem()
+	if pointer, ok := elem.Underlying().(*types.Pointer); ok {
+		elem = pointer.Elem()
+	}
+	if _, ok := elem.Underlying().(*types.Struct); !ok {
+		return nil, fmt.Errorf("element type %s is not a struct", sliceType.Elem())
+	}
+	obj, _, _ := types.LookupFieldOrMethod(elem, false, types.NewPackage(pkgPath, ""), key)
+	field, ok := obj.(*types.Var)
+	if !ok || !field.IsField() {
+		return nil, fmt.Errorf("element type %s has no field %s", sliceType.Elem(), key)
+	}
+	if !types.Comparable(field.Type()) {
+		return nil, fmt.Errorf("key field %s of type %s is not comparable", key, field.Type())
+	}
+	return field, nil
+}
+
+// annotations reads the goequal tags of struct fields and the goequal directives of type declarations in files.
+// typesPkg and defs are the package and the definitions found when type checking the files.
+// Tags and directives are stored by the position of the field or type they belong to,
+// so they can be found for objects of the same package type checked by the loader.
+func (p *pkg) annotations(files []*ast.File, typesPkg *types.Package, defs map[*ast.Ident]types.Object) Errors {
+	var errs Errors
+	p.tags = make(map[token.Pos]fieldTag)
+	p.ignored = make(map[token.Pos]bool)
+	p.annotated = make(map[token.Pos]bool)
+	for _, file := range files {
+		ast.Inspect(file, func(node ast.Node) bool {
+			switch n := node.(type) {
+			case *ast.GenDecl:
+				for _, spec := range n.Specs {
+					typeSpec, ok := spec.(*ast.TypeSpec)
+					if !ok {
+						continue
+					}
+					// a single type declaration has its comments on the declaration
+					if hasDirective(typeSpec.Doc, ignoreDirective) || (!n.Lparen.IsValid() && hasDirective(n.Doc, ignoreDirective)) {
+						p.ignored[typeSpec.Name.Pos()] = true
+					}
+					if hasDirective(typeSpec.Doc, generateDirective) || (!n.Lparen.IsValid() && hasDirective(n.Doc, generateDirective)) {
+						p.annotated[typeSpec.Name.Pos()] = true
+					}
+				}
+			case *ast.StructType:
+				for _, field := range n.Fields.List {
+					if field.Tag == nil {
+						continue
+					}
+					raw, err := strconv.Unquote(field.Tag.Value)
+					if err != nil {
+						continue
+					}
+					value, ok := reflect.StructTag(raw).Lookup("goequal")
+					if !ok {
+						continue
+					}
+					tag, err := parseTag(value)
+					if err != nil {
+						errs = append(errs, &Error{Pos: p.fset.Position(field.Tag.Pos()), Msg: err.Error()})
+						continue
+					}
+					for _, name := range fieldNames(field) {
+						obj := defs[name]
+						if obj == nil {
+							continue
+						}
+						if err := p.checkTag(&tag, typesPkg, obj); err != nil {
+							errs = append(errs, &Error{Pos: p.fset.Position(field.Tag.Pos()), Msg: err.Error()})
+							continue
+						}
+						p.tags[obj.Pos()] = tag
+					}
+				}
+			}
+			return true
+		})
+	}
+	return errs
+}
+
+// resolveFunc finds the function named in the tag of field and checks that it can compare two values of the field.
+// The function is given by name if it is declared in the package of the field, otherwise by import path and name.
+func (p *pkg) resolveFunc(tag *fieldTag, typesPkg *types.Package, field types.Object) error {
+	tag.funcPkgPath, tag.funcName = p.path, tag.function
+	if i := strings.LastIndex(tag.function, "."); i > -1 {
+		tag.funcPkgPath, tag.funcName = tag.function[:i], tag.function[i+1:]
+	}
+	funcPkg := typesPkg
+	if tag.funcPkgPath != p.path {
+		var err error
+		if funcPkg, err = p.resolver.Import(tag.funcPkgPath); err != nil {
+			return fmt.Errorf("can not find function %s of field %s: %s", tag.function, field.Name(), err)
+		}
+		if imports(funcPkg, p.path, make(map[string]bool)) {
+			return fmt.Errorf("package %s imports package %s, calling function %s of field %s would create an import cycle",
+				tag.funcPkgPath, p.path, tag.function, field.Name())
+		}
+	}
+	obj := funcPkg.Scope().Lookup(tag.funcName)
+	if obj == nil {
+		return fmt.Errorf("function %s of field %s was not found in package %s", tag.funcName, field.Name(), tag.funcPkgPath)
+	}
+	expected := fmt.Sprintf("func(a, b %s) bool", types.TypeString(field.Type(), types.RelativeTo(typesPkg)))
+	function, ok := obj.(*types.Func)
+	if !ok {
+		return fmt.Errorf("%s is not a function, field %s needs %s", tag.function, field.Name(), expected)
+	}
+	signature := function.Type().(*types.Signature)
+	if signature.TypeParams().Len() > 0 {
+		return fmt.Errorf("function %s is generic, field %s needs %s", tag.function, field.Name(), expected)
+	}
+	params, results := signature.Params(), signature.Results()
+	if params.Len() != 2 || results.Len() != 1 || signature.Variadic() ||
+		!identical(params.At(0).Type(), field.Type()) || !identical(params.At(1).Type(), field.Type()) ||
+		!identical(results.At(0).Type(), types.Typ[types.Bool]) {
+		return fmt.Errorf("function %s has type %s, field %s needs %s",
+			tag.function, types.TypeString(signature, types.RelativeTo(typesPkg)), field.Name(), expected)
+	}
+	return nil
+}
+
+// imports returns true if pkg imports, directly or indirectly, the package with import path path.
+func imports(pkg *types.Package, path string, visited map[string]bool) bool {
+	for _, imported := range pkg.Imports() {
+		if imported.Path() == path {
+			return true
+		}
+		if !visited[imported.Path()] {
+			visited[imported.Path()] = true
+			if imports(imported, path, visited) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// identical returns true if the two types are identical.
+// Types of a package type checked by the loader are not identical to the types of the same package type checked by us,
+// so we compare also their fully qualified names.
+func identical(t1, t2 types.Type) bool {
+	return types.Identical(t1, t2) || types.TypeString(t1, nil) == types.TypeString(t2, nil)
+}
+
+// hasDirective returns true if the comments have the directive on a line of its own.
+func hasDirective(comments *ast.CommentGroup, directive string) bool {
+	if comments == nil {
+		return false
+	}
+	for _, comment := range comments.List {
+		if strings.TrimSpace(comment.Text) == directive {
+			return true
+		}
+	}
+	return false
+}
+
+// fieldNames returns the identifiers defining the fields of a struct field declaration.
+// An embedded field is defined by the name of its type, e.g.: *pkg.Type[T] is defined by Type.
+func fieldNames(field *ast.Field) []*ast.Ident {
+	if len(field.Names) > 0 {
+		return field.Names
+	}
+	typ := field.Type
+	for {
+		switch t := typ.(type) {
+		case *ast.StarExpr:
+			typ = t.X
+		case *ast.IndexExpr:
+			typ = t.X
+		case *ast.IndexListExpr:
+			typ = t.X
+		case *ast.SelectorExpr:
+			return []*ast.Ident{t.Sel}
+		case *ast.Ident:
+			return []*ast.Ident{t}
+		default:
+			return nil
+		}
+	}
+}
+
+// getTag returns the goequal tag of a struct field.
+func (g *Generator) getTag(field *types.Var) fieldTag {
+	if field.Pkg() == nil {
+		return fieldTag{}
+	}
+	pkgObj := g.getPkg(field.Pkg().Path())
+	if err := pkgObj.checked(); err != nil {
+		g.errs.add(err)
+		return fieldTag{}
+	}
+	return pkgObj.tags[field.Pos()]
+}
+
+// isIgnored returns true if the declaration of the named type has the ignore directive.
+func (g *Generator) isIgnored(obj *types.TypeName) bool {
+	if obj.Pkg() == nil {
+		return false
+	}
+	pkgObj := g.getPkg(obj.Pkg().Path())
+	if err := pkgObj.checked(); err != nil {
+		g.errs.add(err)
+		return false
+	}
+	return pkgObj.ignored[obj.Pos()]
+}
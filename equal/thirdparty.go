@@ -0,0 +1,38 @@
+package equal
+
+import (
+	"fmt"
+	"go/types"
+)
+
+// isForeign returns true if the package is not in the main module, e.g.: a dependency in the module cache or in a vendor directory,
+// so nothing is written in it. Packages of the modules of a go.work workspace are in the main module, and so are packages given as input.
+func (g *Generator) isForeign(pkgPath string) bool {
+	loaded := g.loaded[pkgPath]
+	if loaded == nil {
+		return false
+	}
+	return loaded.Module == nil || !loaded.Module.Main
+}
+
+// parseBody returns the code comparing, hashing or copying the values of the current parsed type, of the underlying type typ.
+// Types of other packages with fields that are not exported are compared as a whole with reflect.DeepEqual, with the UnexportedReflect strategy:
+// they are not hashed, as there is no hash consistent with it, and they are copied by assignment.
+func (g *Generator) parseBody(myType Type, typ types.Type) string {
+	if !g.reflected[myType] {
+		return g.parseType(myType.name, typ, true, false)
+	}
+	name1, name2 := "t1", "t2"
+	if !g.isPointer(typ) {
+		// we artificially introduced pointers here, the values are compared
+		name1, name2 = "(*t1)", "(*t2)"
+	}
+	if g.hash {
+		return ""
+	}
+	if g.clone {
+		return fmt.Sprintf("%s = %s\n", renameParam(name2, "t2", "dst"), renameParam(name1, "t1", "src"))
+	}
+	cond := fmt.Sprintf("!%s(%s, %s)", g.getReferenceUpdateImports("reflect", "DeepEqual"), name1, name2)
+	return g.getCheck(cond, g.getFailure(myType.name, true, "DifferentValues", name1, name2), "")
+}
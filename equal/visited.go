@@ -0,0 +1,35 @@
+package equal
+
+import "reflect"
+
+// Visited is the set of pairs of values compared by generated functions of types whose values can form cycles.
+// The generated functions assume that a pair compared again is equal, as reflect.DeepEqual does,
+// so comparing cyclic values ends.
+type Visited map[visit]struct{}
+
+// visit is a pair of pointers, maps or slices of the same type.
+// Slices are kept with their lengths, as slices of different lengths can share their first element.
+type visit struct {
+	p1, p2 uintptr
+	n1, n2 int
+	typ    reflect.Type
+}
+
+// Visit returns true if the pair of pointers, maps or slices a and b was visited, otherwise it records the pair.
+// Pairs with nil values are not recorded, as they are compared without going further.
+// The values are kept with their types, so pointers to a struct and to its first field are different.
+func (v Visited) Visit(a, b any) bool {
+	v1, v2 := reflect.ValueOf(a), reflect.ValueOf(b)
+	if v1.IsNil() || v2.IsNil() {
+		return false
+	}
+	pair := visit{p1: v1.Pointer(), p2: v2.Pointer(), typ: v1.Type()}
+	if v1.Kind() == reflect.Slice {
+		pair.n1, pair.n2 = v1.Len(), v2.Len()
+	}
+	if _, ok := v[pair]; ok {
+		return true
+	}
+	v[pair] = struct{}{}
+	return false
+}
@@ -9,14 +9,35 @@ import (
 )
 
 func main() {
-	typeName := flag.String("type", "", "Type to generate Equal function for")
-	pkgName := flag.String("package", "", "Package type is part of")
+	typeName := flag.String("type", "", "Types to generate Equal functions for, separated by commas, or regular expressions matching their names, e.g.: Order,^Event.*")
+	pkgName := flag.String("package", "", "Package type is part of, as import path or relative path like ./internal/model, or a pattern like ./...")
 	stdOut := flag.Bool("stdout", false, "Print to stdout")
+	method := flag.Bool("method", false, "Generate Equal methods instead of Equal<Type> functions")
+	diff := flag.Bool("diff", false, "Generate also Diff<Type> functions reporting every difference")
+	hash := flag.Bool("hash", false, "Generate also Hash<Type> functions consistent with Equal<Type>")
+	clone := flag.Bool("clone", false, "Generate also Clone<Type> functions copying what Equal<Type> compares")
+	nanEqual := flag.Bool("nan-equal", false, "Make NaN floating-point numbers equal to each other")
+	semantic := flag.Bool("semantic", false, "Make a nil pointer equal to a pointer to a zero value")
+	dispatch := flag.Bool("dispatch", false, "Compare interface values with the functions of their dynamic types")
+	cycleSafe := flag.Bool("cycle-safe", false, "Make Equal<Type> and Diff<Type> functions end for values forming cycles")
+	register := flag.Bool("register", false, "Register the generated functions, so equal.Interface compares interface values with them")
+	singleFile := flag.Bool("single-file", false, "Write the functions of every package in a single goequal_equal.go file")
+	out := flag.String("out", "", "Package to generate every function in, e.g.: ./internal/equality, comparing only exported fields")
+	all := flag.Bool("all", false, "Generate Equal functions for every named struct, map and slice type of the package")
+	allAnnotated := flag.Bool("all-annotated", false, "Generate Equal functions for every type annotated with //goequal:generate in the packages")
+	unexported := flag.String("unexported", "", "Strategy for fields that are not exported of types compared outside their package: skip or reflect")
 	flag.Parse()
-	if *typeName == "" || *pkgName == "" {
+	log.SetFlags(0)
+	log.SetPrefix("goequal: ")
+	if (*typeName == "" && !*all && !*allAnnotated) || *pkgName == "" {
 		log.Println("You have to specify type and package")
 		os.Exit(2)
 	}
-	generator := equal.NewGenerator(*pkgName, *typeName, *stdOut, nil)
-	generator.Generate()
+	generator := equal.NewGenerator(*pkgName, *typeName, *stdOut, nil, equal.Options{
+		Method: *method, Diff: *diff, Hash: *hash, Clone: *clone, NaNEqual: *nanEqual, Semantic: *semantic, Dispatch: *dispatch, Register: *register,
+		CycleSafe: *cycleSafe, SingleFile: *singleFile, Out: *out, All: *all, AllAnnotated: *allAnnotated, Unexported: *unexported,
+	})
+	if err := generator.Generate(); err != nil {
+		log.Fatal(err)
+	}
 }